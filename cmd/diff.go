@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"ghconfig/internal/config"
+	"ghconfig/internal/helper"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/apex/log"
+	"github.com/fatih/color"
+	"github.com/pieterclaerhout/go-waitgroup"
+)
+
+// NewDiffCmd prints a unified diff between the remote files and the files ghconfig would push.
+// It reports whether any repository has pending changes.
+func NewDiffCmd(globalOptions *config.Config) (bool, error) {
+	plans, err := planRepositories(globalOptions)
+	if err != nil {
+		return false, err
+	}
+
+	wg := waitgroup.NewWaitGroup(helper.Concurrency(globalOptions, 3))
+	var results = make(chan *config.RepositoryUpdate, len(plans))
+	var failures int32
+
+	bar := newProgressBar(len(plans))
+
+	for _, p := range plans {
+		plan := p
+		wg.Add(func() {
+			defer advanceProgressBar(globalOptions, bar)
+
+			if globalOptions.Context.Err() != nil {
+				atomic.AddInt32(&failures, 1)
+				return
+			}
+
+			if budgetExhausted(globalOptions) {
+				log.WithField("repository", plan.Repository.GetFullName()).Warn("skip repository because the request budget is exhausted")
+				atomic.AddInt32(&failures, 1)
+				return
+			}
+
+			update := newRepositoryUpdate(globalOptions, plan)
+
+			err := prepareRepositoryFiles(globalOptions, update, plan.TemplateSet)
+			if err != nil {
+				atomic.AddInt32(&failures, 1)
+				return
+			}
+
+			err = validateRepositoryFiles(update)
+			if err != nil {
+				atomic.AddInt32(&failures, 1)
+				return
+			}
+
+			// the files can't be synchronized, the problems are reported instead of the diff
+			if len(update.SchemaErrors) > 0 || len(update.Conflicts) > 0 {
+				atomic.AddInt32(&failures, 1)
+				update.Files = nil
+				results <- update
+				return
+			}
+
+			dropUnchangedFiles(update)
+
+			results <- update
+		})
+	}
+
+	wg.Wait()
+	close(results)
+
+	updates := []*config.RepositoryUpdate{}
+	for pkg := range results {
+		updates = append(updates, pkg)
+	}
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].Repository.GetFullName() < updates[j].Repository.GetFullName()
+	})
+
+	fmt.Print("\n\n")
+
+	hasChanges := false
+	for _, update := range updates {
+		for _, file := range update.Files {
+			remoteFileContent := []byte{}
+			if file.RepositoryUpdateOptions.RemoteFileContent != nil {
+				remoteFileContent = *file.RepositoryUpdateOptions.RemoteFileContent
+			}
+			diff, err := helper.UnifiedDiff(file.RepositoryUpdateOptions.Path, remoteFileContent, *file.RepositoryUpdateOptions.FileContent)
+			if err != nil {
+				log.WithError(err).Errorf("could not diff %v", file.RepositoryUpdateOptions.DisplayName)
+				atomic.AddInt32(&failures, 1)
+				continue
+			}
+			if diff == "" {
+				continue
+			}
+			hasChanges = true
+			printDiff(update.Repository.GetFullName(), diff)
+		}
+	}
+
+	printSchemaErrors(updates)
+	printMergeConflicts(updates)
+
+	if failures > 0 {
+		return hasChanges, fmt.Errorf("could not diff %d repositories", failures)
+	}
+
+	return hasChanges, nil
+}
+
+func printDiff(repositoryName string, diff string) {
+	color.New(color.Bold).Printf("# Repository: %v\n", repositoryName)
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			color.New(color.Bold).Print(line)
+		case strings.HasPrefix(line, "@@"):
+			color.New(color.FgCyan).Print(line)
+		case strings.HasPrefix(line, "+"):
+			color.New(color.FgGreen).Print(line)
+		case strings.HasPrefix(line, "-"):
+			color.New(color.FgRed).Print(line)
+		default:
+			fmt.Print(line)
+		}
+	}
+	fmt.Print("\n")
+}
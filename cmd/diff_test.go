@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"ghconfig/internal/config"
+	gh "ghconfig/internal/github"
+	"net/http"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/tj/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDiff_WorkflowExistOnRemote(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[
+		{
+		  "type": "file",
+		  "name": "ci.yaml",
+		  "path": ".github/workflows/ci.yaml",
+		  "sha": "sha"
+		}]`)
+	})
+	remoteFile, _ := yaml.Marshal(&gh.GithubWorkflow{
+		Name: "remote",
+		Jobs: map[string]*gh.Job{
+			"build": {RunsOn: "ubuntu-latest"},
+		},
+	})
+	handleRepositoryFile(t, mux, "o/r", ".github/workflows/ci.yaml", remoteFile)
+	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("diff must not create branches")
+	})
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-workflow",
+		PreserveFormat:  true,
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	hasChanges, err := NewDiffCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+	assert.True(t, hasChanges)
+
+	for _, entry := range h.Entries {
+		if entry.Level >= log.ErrorLevel {
+			t.Errorf("stderr should be empty, error: %v", entry)
+		}
+	}
+}
+
+func TestDiff_LargeRemoteFile(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[
+		{
+		  "type": "file",
+		  "name": "ci.yaml",
+		  "path": ".github/workflows/ci.yaml",
+		  "sha": "sha"
+		}]`)
+	})
+	remoteFile, _ := yaml.Marshal(&gh.GithubWorkflow{
+		Name: "remote",
+		Jobs: map[string]*gh.Job{
+			"build": {RunsOn: "ubuntu-latest"},
+		},
+	})
+	// files larger than 1 MB are not inlined by the contents API
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows/ci.yaml", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"type": "file", "name": "ci.yaml", "path": ".github/workflows/ci.yaml", "sha": "blobsha", "size": 2000000, "encoding": "none", "content": ""}`)
+	})
+	mux.HandleFunc("/repos/o/r/git/blobs/blobsha", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testHeader(t, r, "Accept", "application/vnd.github.v3.raw")
+		w.Write(remoteFile)
+	})
+	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("diff must not create branches")
+	})
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-workflow",
+		PreserveFormat:  true,
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	hasChanges, err := NewDiffCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+	assert.True(t, hasChanges)
+
+	for _, entry := range h.Entries {
+		if entry.Level >= log.ErrorLevel {
+			t.Errorf("stderr should be empty, error: %v", entry)
+		}
+	}
+}
+
+func TestDiff_SchemaErrors(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/invalid-workflow",
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	// the invalid workflow is reported instead of its diff
+	hasChanges, err := NewDiffCmd(cfg)
+	assert.EqualError(t, err, "could not diff 1 repositories")
+	assert.False(t, hasChanges)
+}
+
+func TestDiff_MergeConflicts(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	// the template and the repository changed the npm interval
+	base := `
+version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /
+    schedule:
+      interval: weekly
+`
+	remote := `
+version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /
+    schedule:
+      interval: monthly
+`
+	handleThreeWayRepository(t, mux, remote, base)
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-dependabot",
+		ThreeWayMerge:   true,
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	hasChanges, err := NewDiffCmd(cfg)
+	assert.EqualError(t, err, "could not diff 1 repositories")
+	assert.False(t, hasChanges)
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"ghconfig/internal/config"
+	"ghconfig/internal/helper"
+	"path"
+	"path/filepath"
+
+	"github.com/apex/log"
+	"github.com/google/go-github/v32/github"
+)
+
+// repositoryPlan is a repository together with the templates which are applied to it.
+type repositoryPlan struct {
+	Repository   *github.Repository
+	TemplateSet  *config.TemplateSet
+	TemplateVars config.TemplateVars
+	// Target is the name of the manifest target
+	Target string
+}
+
+// planRepositories selects all repositories and their template sets. A ghconfig.yaml manifest
+// in the root directory replaces the interactive repository selection.
+func planRepositories(globalOptions *config.Config) ([]*repositoryPlan, error) {
+	manifest, err := helper.LoadManifest(globalOptions.RootDir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		plans, err := planManifest(globalOptions, manifest)
+		if err != nil {
+			return nil, err
+		}
+		return filterPlans(globalOptions, plans)
+	}
+
+	templateSet, err := findTemplateSet(globalOptions.RootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	repos, targetRepos, err := selectRepositories(globalOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := []*repositoryPlan{}
+	for _, name := range targetRepos {
+		plans = append(plans, &repositoryPlan{
+			Repository:  getRepoByName(repos, name),
+			TemplateSet: templateSet,
+		})
+	}
+
+	return plans, nil
+}
+
+// planManifest assigns every repository to the first target of the manifest whose selector matches.
+func planManifest(globalOptions *config.Config, manifest *config.Manifest) ([]*repositoryPlan, error) {
+	// repositories are fetched once per search query
+	reposByQuery := map[string][]*github.Repository{}
+	assigned := map[string]bool{}
+	plans := []*repositoryPlan{}
+
+	for _, target := range manifest.Targets {
+		templateSet, err := loadTargetTemplateSet(globalOptions.RootDir, target)
+		if err != nil {
+			log.WithError(err).Errorf("could not load templates of target %v", target.Name)
+			return nil, err
+		}
+
+		query := target.Selector.Query
+		repos, ok := reposByQuery[query]
+		if !ok {
+			if query == "" {
+				repos, err = helper.FetchAllRepos(globalOptions)
+			} else {
+				repos, err = helper.SearchRepos(globalOptions, query)
+			}
+			if err != nil {
+				log.WithError(err).Errorf("could not fetch repositories of target %v", target.Name)
+				return nil, err
+			}
+			repos = activeRepositories(repos)
+			reposByQuery[query] = repos
+		}
+
+		for _, repo := range repos {
+			if assigned[repo.GetFullName()] || !helper.MatchesSelector(&target.Selector, repo) {
+				continue
+			}
+			assigned[repo.GetFullName()] = true
+			log.Debugf("repository %v is assigned to target %v", repo.GetFullName(), target.Name)
+
+			plans = append(plans, &repositoryPlan{
+				Repository:   repo,
+				TemplateSet:  templateSet,
+				TemplateVars: target.TemplateVars,
+				Target:       target.Name,
+			})
+		}
+	}
+
+	return plans, nil
+}
+
+// filterPlans narrows the repositories of the manifest down with the selection flags
+// (--include, --exclude and --repos-file). --all selects all repositories of the manifest.
+func filterPlans(globalOptions *config.Config, plans []*repositoryPlan) ([]*repositoryPlan, error) {
+	if !hasSelectionOptions(globalOptions) {
+		return plans, nil
+	}
+
+	names := []string{}
+	for _, plan := range plans {
+		names = append(names, plan.Repository.GetFullName())
+	}
+	targetRepos, err := filterRepositoryNames(globalOptions, names)
+	if err != nil {
+		log.WithError(err).Error("could not select repositories")
+		return nil, err
+	}
+
+	filtered := []*repositoryPlan{}
+	for _, plan := range plans {
+		if containsName(targetRepos, plan.Repository.GetFullName()) {
+			filtered = append(filtered, plan)
+		}
+	}
+	return filtered, nil
+}
+
+// loadTargetTemplateSet combines all template directories, patches and the dependabot file of a target.
+// Workflows of later directories replace workflows with the same filename.
+func loadTargetTemplateSet(rootDir string, target *config.ManifestTarget) (*config.TemplateSet, error) {
+	templateSet := &config.TemplateSet{
+		Workflows: []*config.WorkflowTemplate{},
+		Patches:   []*config.PatchData{},
+	}
+
+	for _, dir := range target.Templates {
+		dirTemplateSet, err := loadTemplateSet(resolvePath(rootDir, dir))
+		if err != nil {
+			return nil, err
+		}
+		for _, workflow := range dirTemplateSet.Workflows {
+			templateSet.Workflows = replaceWorkflowTemplate(templateSet.Workflows, workflow)
+		}
+		templateSet.Patches = append(templateSet.Patches, dirTemplateSet.Patches...)
+		if dirTemplateSet.Dependabot != nil {
+			templateSet.Dependabot = dirTemplateSet.Dependabot
+		}
+	}
+
+	for _, dir := range target.Patches {
+		patches, err := helper.FindPatches(resolvePath(rootDir, dir))
+		if err != nil {
+			return nil, err
+		}
+		templateSet.Patches = append(templateSet.Patches, patches...)
+	}
+
+	if target.Dependabot != "" {
+		dependabotTemplate, err := helper.ReadDependabot(resolvePath(rootDir, target.Dependabot))
+		if err != nil {
+			return nil, err
+		}
+		templateSet.Dependabot = dependabotTemplate
+	}
+
+	return templateSet, nil
+}
+
+func replaceWorkflowTemplate(workflows []*config.WorkflowTemplate, workflow *config.WorkflowTemplate) []*config.WorkflowTemplate {
+	for i, w := range workflows {
+		if w.Filename == workflow.Filename {
+			workflows[i] = workflow
+			return workflows
+		}
+	}
+	return append(workflows, workflow)
+}
+
+func resolvePath(rootDir, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return path.Join(rootDir, p)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"ghconfig/internal/config"
+	"net/http"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/tj/assert"
+)
+
+func TestSync_Manifest(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		switch r.FormValue("q") {
+		case "org:o language:javascript":
+			fmt.Fprint(w, `{"total_count": 2, "incomplete_results": false, "items": [
+				{"id":2, "name": "node-a", "full_name": "o/node-a", "owner": {"id":1, "Login": "o"}},
+				{"id":3, "name": "web", "full_name": "o/web", "owner": {"id":1, "Login": "o"}}
+			]}`)
+		case "o in:name":
+			fmt.Fprint(w, `{"total_count": 3, "incomplete_results": false, "items": [
+				{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}},
+				{"id":2, "name": "node-a", "full_name": "o/node-a", "owner": {"id":1, "Login": "o"}},
+				{"id":3, "name": "web", "full_name": "o/web", "owner": {"id":1, "Login": "o"}}
+			]}`)
+		default:
+			t.Errorf("unexpected search query %v", r.FormValue("q"))
+		}
+	})
+	for _, repo := range []string{"o/r", "o/node-a"} {
+		mux.HandleFunc("/repos/"+repo+"/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "GET")
+			fmt.Fprint(w, `[]`)
+		})
+	}
+
+	baseCommit := handleRepositoryCommit(t, mux, "o/r", "master")
+	nodeCommit := handleRepositoryCommit(t, mux, "o/node-a", "master")
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/manifest",
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	for _, entry := range h.Entries {
+		assert.NotEqual(t, log.ErrorLevel, entry.Level, entry.Message)
+	}
+
+	assert.Len(t, baseCommit.Blobs, 1)
+	assert.Contains(t, string(baseCommit.Blobs[0]), "make test")
+
+	// the node target replaces the base workflow and adds the dependabot file
+	assert.Len(t, nodeCommit.Blobs, 2)
+	assert.Contains(t, string(nodeCommit.Blobs[0]), "npm test")
+	assert.Contains(t, string(nodeCommit.Blobs[0]), "TEAM: frontend")
+	assert.Contains(t, string(nodeCommit.Blobs[1]), "package-ecosystem: npm")
+}
+
+func TestSync_ManifestSelectionFlags(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		switch r.FormValue("q") {
+		case "org:o language:javascript":
+			fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [
+				{"id":2, "name": "node-a", "full_name": "o/node-a", "owner": {"id":1, "Login": "o"}}
+			]}`)
+		case "o in:name":
+			fmt.Fprint(w, `{"total_count": 2, "incomplete_results": false, "items": [
+				{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}},
+				{"id":2, "name": "node-a", "full_name": "o/node-a", "owner": {"id":1, "Login": "o"}}
+			]}`)
+		default:
+			t.Errorf("unexpected search query %v", r.FormValue("q"))
+		}
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/o/node-a/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("the excluded repository o/node-a should not be processed")
+	})
+
+	baseCommit := handleRepositoryCommit(t, mux, "o/r", "master")
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/manifest",
+		ExcludePatterns: []string{"o/node-*"},
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	assert.Len(t, baseCommit.Blobs, 1)
+}
@@ -1,18 +1,20 @@
 package cmd
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/google/go-github/v32/github"
+	"gopkg.in/yaml.v3"
 )
 
 type (
@@ -76,13 +78,6 @@ func testMethod(t *testing.T, r *http.Request, want string) {
 
 type values map[string]string
 
-func readRepositoryContentFileOptions(r io.ReadCloser) github.RepositoryContentFileOptions {
-	var result github.RepositoryContentFileOptions
-	bytes, _ := ioutil.ReadAll(r)
-	json.Unmarshal(bytes, &result)
-	return result
-}
-
 func testFormValues(t *testing.T, r *http.Request, values values) {
 	t.Helper()
 	want := url.Values{}
@@ -105,3 +100,165 @@ func testHeader(t *testing.T, r *http.Request, header string, want string) {
 		t.Errorf("Header.Get(%q) returned %q, want %q", header, got, want)
 	}
 }
+
+// remoteFiles are the paths of the files which are served by handleRepositoryFile per test server
+// and repository. They are listed in the tree of the base commit with the blob SHA "sha".
+var remoteFiles = struct {
+	sync.Mutex
+	paths map[remoteFilesKey][]string
+	trees map[remoteFilesKey]bool
+}{paths: map[remoteFilesKey][]string{}, trees: map[remoteFilesKey]bool{}}
+
+type remoteFilesKey struct {
+	mux  *http.ServeMux
+	repo string
+}
+
+func addRemoteFile(mux *http.ServeMux, repo, filePath string) {
+	remoteFiles.Lock()
+	defer remoteFiles.Unlock()
+	key := remoteFilesKey{mux, repo}
+	remoteFiles.paths[key] = append(remoteFiles.paths[key], filePath)
+}
+
+// handleBaseTree serves the tree of the base commit once per test server and repository.
+func handleBaseTree(t *testing.T, mux *http.ServeMux, repo string) {
+	remoteFiles.Lock()
+	defer remoteFiles.Unlock()
+	key := remoteFilesKey{mux, repo}
+	if remoteFiles.trees[key] {
+		return
+	}
+	remoteFiles.trees[key] = true
+
+	mux.HandleFunc("/repos/"+repo+"/git/trees/9fb037999f264ba9a7fc6274d15fa3ae2ab98312", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		remoteFiles.Lock()
+		defer remoteFiles.Unlock()
+		tree := &github.Tree{SHA: github.String("9fb037999f264ba9a7fc6274d15fa3ae2ab98312")}
+		for _, filePath := range remoteFiles.paths[key] {
+			tree.Entries = append(tree.Entries, &github.TreeEntry{
+				Path: github.String(filePath),
+				Type: github.String("blob"),
+				SHA:  github.String("sha"),
+			})
+		}
+		json.NewEncoder(w).Encode(tree)
+	})
+}
+
+type commitRecorder struct {
+	sync.Mutex
+	Blobs   [][]byte
+	Tree    []*github.TreeEntry
+	Message string
+	Force   bool
+}
+
+// handleCommit registers all Git Data API endpoints which are used to commit
+// files on the branch of the repository o/r. The returned recorder contains
+// the created blobs, tree entries and the commit message.
+func handleCommit(t *testing.T, mux *http.ServeMux, branch string) *commitRecorder {
+	return handleRepositoryCommit(t, mux, "o/r", branch)
+}
+
+// handleRepositoryCommit is like handleCommit for the repository with the full name repo.
+func handleRepositoryCommit(t *testing.T, mux *http.ServeMux, repo, branch string) *commitRecorder {
+	recorder := &commitRecorder{}
+	handleBaseTree(t, mux, repo)
+
+	mux.HandleFunc("/repos/"+repo+"/git/ref/heads/"+branch, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"ref": "refs/heads/`+branch+`", "object": {"type": "commit", "sha": "aa218f56b14c9653891f9e74264a383fa43fefbd"}}`)
+	})
+	mux.HandleFunc("/repos/"+repo+"/git/commits/aa218f56b14c9653891f9e74264a383fa43fefbd", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"sha": "aa218f56b14c9653891f9e74264a383fa43fefbd", "tree": {"sha": "9fb037999f264ba9a7fc6274d15fa3ae2ab98312"}}`)
+	})
+	mux.HandleFunc("/repos/"+repo+"/git/blobs", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		v := new(github.Blob)
+		json.NewDecoder(r.Body).Decode(v)
+		content, err := base64.StdEncoding.DecodeString(v.GetContent())
+		if err != nil {
+			t.Errorf("blob content is not base64 encoded: %v", err)
+		}
+		recorder.Lock()
+		recorder.Blobs = append(recorder.Blobs, content)
+		recorder.Unlock()
+		fmt.Fprint(w, `{"sha": "3a0f86fb8db8eea7ccbb9a95f325ddbedfb25e15"}`)
+	})
+	mux.HandleFunc("/repos/"+repo+"/git/trees", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		v := new(struct {
+			BaseTree string              `json:"base_tree"`
+			Tree     []*github.TreeEntry `json:"tree"`
+		})
+		json.NewDecoder(r.Body).Decode(v)
+		if v.BaseTree != "9fb037999f264ba9a7fc6274d15fa3ae2ab98312" {
+			t.Errorf("base_tree = %v, want %v", v.BaseTree, "9fb037999f264ba9a7fc6274d15fa3ae2ab98312")
+		}
+		recorder.Lock()
+		recorder.Tree = v.Tree
+		recorder.Unlock()
+		fmt.Fprint(w, `{"sha": "cd8274d15fa3ae2ab983129fb037999f264ba9a7"}`)
+	})
+	mux.HandleFunc("/repos/"+repo+"/git/commits", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		v := new(github.Commit)
+		json.NewDecoder(r.Body).Decode(v)
+		recorder.Lock()
+		recorder.Message = v.GetMessage()
+		recorder.Unlock()
+		fmt.Fprint(w, `{"sha": "f5f369044773ff9c6383c087466d12adb6fa0828", "html_url": "https://github.com/o/r/commit/f5f369044773ff9c6383c087466d12adb6fa0828"}`)
+	})
+	mux.HandleFunc("/repos/"+repo+"/git/refs/heads/"+branch, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+		v := new(struct {
+			SHA   string `json:"sha"`
+			Force bool   `json:"force"`
+		})
+		json.NewDecoder(r.Body).Decode(v)
+		recorder.Lock()
+		recorder.Force = v.Force
+		recorder.Unlock()
+		fmt.Fprint(w, `{"ref": "refs/heads/`+branch+`", "object": {"type": "commit", "sha": "f5f369044773ff9c6383c087466d12adb6fa0828"}}`)
+	})
+
+	return recorder
+}
+
+// handleRepositoryFile serves the file through the contents API with base64 encoded content.
+func handleRepositoryFile(t *testing.T, mux *http.ServeMux, repo, filePath string, data []byte) {
+	addRemoteFile(mux, repo, filePath)
+	mux.HandleFunc("/repos/"+repo+"/contents/"+filePath, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		json.NewEncoder(w).Encode(&github.RepositoryContent{
+			Type:     github.String("file"),
+			Name:     github.String(path.Base(filePath)),
+			Path:     github.String(filePath),
+			SHA:      github.String("sha"),
+			Encoding: github.String("base64"),
+			Content:  github.String(base64.StdEncoding.EncodeToString(data)),
+		})
+	})
+}
+
+// pullRequestBody returns the PR body of ghconfig with the list of changed files.
+func pullRequestBody(files string) string {
+	return "This Pull-Request is managed by [ghconfig](https://github.com/StarpTech/ghconfig) and updated on every run.\n\nChanged files:\n\n" + files
+}
+
+// yamlTree returns the generic YAML tree of v so that values can be compared
+// independent of unexported state and key order.
+func yamlTree(t *testing.T, v interface{}) interface{} {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatalf("could not marshal, %v", err)
+	}
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		t.Fatalf("could not unmarshal, %v", err)
+	}
+	return tree
+}
@@ -1,16 +1,26 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"ghconfig/internal/common"
 	"ghconfig/internal/config"
 	"ghconfig/internal/dependabot"
 	gh "ghconfig/internal/github"
 	"ghconfig/internal/helper"
-	"io/ioutil"
-	"net/http"
+	"ghconfig/internal/journal"
+	"ghconfig/internal/report"
 	"os"
 	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
@@ -50,137 +60,63 @@ var Multiselect = func(reposNames []string, targetRepos *[]string) error {
 }
 
 func NewSyncCmd(globalOptions *config.Config) error {
-	workflowDirAbs := path.Join(globalOptions.RootDir, config.GhConfigBaseDir, config.GhWorkflowDir)
-	templates, err := helper.FindWorkflows(workflowDirAbs)
+	plans, err := planRepositories(globalOptions)
 	if err != nil {
 		return err
 	}
 
-	workflowPatchesDirAbs := path.Join(globalOptions.RootDir, config.GhConfigBaseDir, config.GhWorkflowDir, config.GhPatchesDir)
-	patches, err := helper.FindPatches(workflowPatchesDirAbs)
-	if err != nil {
-		return err
-	}
-
-	dependabotAbs := path.Join(globalOptions.RootDir, config.GhConfigBaseDir)
-	dependabotTemplate, err := helper.FindDependabot(dependabotAbs)
-	if err != nil {
-		return err
+	var runJournal *journal.Journal
+	if globalOptions.JournalFile != "" && !globalOptions.DryRun {
+		runJournal, err = journal.Open(globalOptions.JournalFile)
+		if err != nil {
+			return fmt.Errorf("could not open journal: %w", err)
+		}
 	}
 
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = " Collecting all available repositories..."
-	s.Start()
-
-	repos, err := helper.FetchAllRepos(globalOptions)
-	if err != nil {
-		return err
-	}
-	s.Stop()
+	// outstanding work is cancelled when the failure policy aborts the run
+	ctx, cancel := context.WithCancel(globalOptions.Context)
+	defer cancel()
+	opts := *globalOptions
+	opts.Context = ctx
 
-	reposNames := []string{}
+	wg := waitgroup.NewWaitGroup(helper.Concurrency(globalOptions, 3))
+	var results = make(chan *config.RepositoryUpdate, len(plans))
+	var failures int32
 
-	for _, repo := range repos {
-		reposNames = append(reposNames, *repo.FullName)
-	}
+	bar := newProgressBar(len(plans))
 
-	targetRepos := []string{}
-	err = Multiselect(reposNames, &targetRepos)
-	if err != nil {
-		log.WithError(err).Error("could not create multi select for repository selection")
-		return err
-	}
-
-	wg := waitgroup.NewWaitGroup(3)
-	var results = make(chan *config.RepositoryUpdate, len(targetRepos))
-
-	bar := progressbar.NewOptions(len(targetRepos),
-		progressbar.OptionSetWriter(ansi.NewAnsiStdout()),
-		progressbar.OptionEnableColorCodes(true),
-		progressbar.OptionSetWidth(15),
-		progressbar.OptionSetDescription("Processing repositories..."),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        "[green]=[reset]",
-			SaucerHead:    "[green]>[reset]",
-			SaucerPadding: " ",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}))
-
-	for _, name := range targetRepos {
-		repositoryName := name
+	for _, p := range plans {
+		plan := p
 		wg.Add(func() {
-			defer bar.Add(1)
-			repo := getRepoByName(repos, repositoryName)
-			branchName := globalOptions.BaseBranch
-
-			ctx := log.WithFields(log.Fields{
-				"repository": repositoryName,
-			})
-
-			if globalOptions.CreatePR {
-				branchName = fmt.Sprintf(config.BranchNamePattern, globalOptions.Sid.MustGenerate())
-			}
+			defer advanceProgressBar(&opts, bar)
 
-			updateOptions := &config.RepositoryUpdateOptions{
-				Owner:       *repo.GetOwner().Login,
-				Repo:        repo.GetName(),
-				BaseRef:     globalOptions.BaseBranch,
-				Branch:      branchName,
-				PRBranchRef: "refs/heads/" + branchName,
+			if ctx.Err() != nil {
+				update := newRepositoryUpdate(&opts, plan)
+				update.Status = config.StatusNotStarted
+				results <- update
+				return
 			}
 
-			update := &config.RepositoryUpdate{
-				RepositoryOptions: updateOptions,
-				Repository:        repo,
-				TemplateVars:      map[string]interface{}{"Repo": repo},
+			revision := templateRevision(&opts, plan)
+			if opts.Resume && runJournal != nil && runJournal.Completed(plan.Repository.GetFullName(), revision) {
+				results <- resumeRepository(&opts, plan, runJournal.Get(plan.Repository.GetFullName()))
+				return
 			}
 
-			if !globalOptions.PatchOnly {
-				files, err := prepareWorkflows(globalOptions, update, templates)
-				if err != nil {
-					ctx.WithError(err).Error("could not prepare workflow files")
-					return
-				}
-				update.Files = append(update.Files, files...)
-
-				if dependabotTemplate != nil {
-					fileUpdate, err := prepareDependabot(globalOptions, update, dependabotTemplate)
-					if err != nil {
-						log.WithError(err).Error("could not prepare dependabot file")
-						return
-					}
-					update.Files = append(update.Files, fileUpdate)
+			update := syncRepository(&opts, plan)
+			if update.Status == config.StatusFailed {
+				if ctx.Err() != nil {
+					// in-flight requests were cancelled by another failure
+					update.Status = config.StatusSkipped
+					update.Errors = append(update.Errors, "run was aborted")
+				} else if abortRun(&opts, int(atomic.AddInt32(&failures, 1))) {
+					log.Warnf("abort run after %d failed repositories", atomic.LoadInt32(&failures))
+					cancel()
 				}
-			} else {
-				files, err := preparePatches(globalOptions, update, patches)
-				if err != nil {
-					ctx.WithError(err).Error("could not prepare patch files")
-					return
-				}
-				update.Files = append(update.Files, files...)
 			}
-
-			if len(update.Files) > 0 {
-				if !globalOptions.DryRun {
-					if globalOptions.CreatePR {
-						pullRequestURL, err := helper.CreatePR(globalOptions, update)
-						if err != nil {
-							ctx.WithError(err).Error("could not create PR with changes")
-							return
-						}
-						update.PullRequestURL = pullRequestURL
-					} else {
-						// update files directly on the base branch
-						err := helper.UpdateRepositoryFiles(globalOptions, update.RepositoryOptions, update.Files)
-						if err != nil {
-							ctx.WithError(err).Error("could not update files on remote")
-							return
-						}
-					}
-				}
+			if runJournal != nil {
+				recordRepository(runJournal, update, revision)
 			}
-
 			results <- update
 		})
 	}
@@ -188,24 +124,42 @@ func NewSyncCmd(globalOptions *config.Config) error {
 	wg.Wait()
 	close(results)
 
-	s.Stop()
-
 	updates := []*config.RepositoryUpdate{}
 	for pkg := range results {
 		updates = append(updates, pkg)
 	}
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].Repository.GetFullName() < updates[j].Repository.GetFullName()
+	})
 
 	table := tabby.New()
 	table.AddHeader("Repository", "Pull-Request")
 
 	// build table for cli output
 	for _, pkg := range updates {
-		table.AddLine(pkg.Repository.GetFullName(), pkg.PullRequestURL)
+		switch pkg.Status {
+		case config.StatusUnchanged:
+			table.AddLine(pkg.Repository.GetFullName(), "up to date")
+		case config.StatusUpdated:
+			table.AddLine(pkg.Repository.GetFullName(), pkg.PullRequestURL)
+		default:
+			table.AddLine(pkg.Repository.GetFullName(), pkg.Status)
+		}
 	}
 
 	fmt.Print("\n\n")
 	table.Print()
 
+	printSchemaErrors(updates)
+	printMergeConflicts(updates)
+
+	if globalOptions.ReportFile != "" {
+		err := report.WriteFile(globalOptions.ReportFile, globalOptions.ReportFormat, report.New(updates))
+		if err != nil {
+			log.WithError(err).Errorf("could not write report %v", globalOptions.ReportFile)
+		}
+	}
+
 	if globalOptions.DryRun {
 		file, err := os.Create(path.Join(globalOptions.RootDir, "ghconfig-debug.yml"))
 		if err != nil {
@@ -241,116 +195,624 @@ func NewSyncCmd(globalOptions *config.Config) error {
 		fmt.Printf("\nData has been written to ghconfig-debug.yml\n")
 	}
 
+	printRunSummary(updates)
+
+	if globalOptions.Context.Err() != nil {
+		return fmt.Errorf("run was interrupted")
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("run aborted after %d failed repositories", failures)
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d repositories failed", failures, len(plans))
+	}
+
 	return nil
 }
 
-func preparePatches(opts *config.Config, update *config.RepositoryUpdate, patches []*config.PatchData) ([]*config.RepositoryFileUpdate, error) {
-	files := []*config.RepositoryFileUpdate{}
+// printSchemaErrors prints a table of the schema errors of all repositories.
+func printSchemaErrors(updates []*config.RepositoryUpdate) {
+	schemaTable := tabby.New()
+	schemaTable.AddHeader("Repository", "File", "Field", "Schema-Error")
 
-	for _, patch := range patches {
-		remoteFilePath := path.Join(config.GithubConfigBaseDir, "workflows", patch.Filename)
-		content, _, resp, err := opts.GithubClient.Repositories.GetContents(
-			opts.Context,
-			update.RepositoryOptions.Owner,
-			update.RepositoryOptions.Repo,
-			remoteFilePath,
-			&github.RepositoryContentGetOptions{
-				Ref: update.RepositoryOptions.BaseRef,
-			},
-		)
+	hasSchemaErrors := false
+	for _, pkg := range updates {
+		for _, schemaError := range pkg.SchemaErrors {
+			hasSchemaErrors = true
+			schemaTable.AddLine(pkg.Repository.GetFullName(), schemaError.Filename, schemaError.Field, schemaError.Description)
+		}
+	}
+
+	if hasSchemaErrors {
+		fmt.Print("\n")
+		schemaTable.Print()
+	}
+}
+
+// printMergeConflicts prints a table of the merge conflicts of all repositories.
+func printMergeConflicts(updates []*config.RepositoryUpdate) {
+	conflictTable := tabby.New()
+	conflictTable.AddHeader("Repository", "File", "Path", "Merge-Conflict")
+
+	hasConflicts := false
+	for _, pkg := range updates {
+		for _, conflict := range pkg.Conflicts {
+			hasConflicts = true
+			conflictTable.AddLine(pkg.Repository.GetFullName(), conflict.Filename, conflict.Path, "changed in the template and the remote file")
+		}
+	}
+
+	if hasConflicts {
+		fmt.Print("\n")
+		conflictTable.Print()
+	}
+}
+
+// templateRevision identifies the templates and variables which are applied to a repository.
+func templateRevision(globalOptions *config.Config, plan *repositoryPlan) string {
+	data, err := yaml.Marshal(struct {
+		TemplateSet  *config.TemplateSet
+		TemplateVars config.TemplateVars
+		PatchOnly    bool
+		BaseBranch   string
+	}{plan.TemplateSet, plan.TemplateVars, globalOptions.PatchOnly, globalOptions.BaseBranch})
+	if err != nil {
+		log.WithError(err).Warn("could not compute template revision")
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// resumeRepository reports the outcome of a previous run instead of processing the repository again.
+func resumeRepository(globalOptions *config.Config, plan *repositoryPlan, entry *journal.Entry) *config.RepositoryUpdate {
+	log.WithField("repository", plan.Repository.GetFullName()).Infof("skip repository because it was completed at %v", entry.UpdatedAt)
+
+	update := newRepositoryUpdate(globalOptions, plan)
+	update.Status = entry.Status
+	update.PullRequestURL = entry.PullRequestURL
+	update.CommitSHA = entry.CommitSHA
+	return update
+}
+
+func recordRepository(runJournal *journal.Journal, update *config.RepositoryUpdate, revision string) {
+	err := runJournal.Record(update.Repository.GetFullName(), &journal.Entry{
+		Status:         update.Status,
+		Revision:       revision,
+		Branch:         update.RepositoryOptions.Branch,
+		CommitSHA:      update.CommitSHA,
+		PullRequestURL: update.PullRequestURL,
+		Errors:         update.Errors,
+	})
+	if err != nil {
+		log.WithError(err).Error("could not write journal")
+	}
+}
+
+// printRunSummary lists the repositories which weren't started so that a cancelled run can be resumed.
+func printRunSummary(updates []*config.RepositoryUpdate) {
+	notStarted := []string{}
+	for _, update := range updates {
+		if update.Status == config.StatusNotStarted {
+			notStarted = append(notStarted, update.Repository.GetFullName())
+		}
+	}
+	if len(notStarted) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%d repositories completed, %d not started (use --resume to continue):\n", len(updates)-len(notStarted), len(notStarted))
+	for _, name := range notStarted {
+		fmt.Println(name)
+	}
+}
+
+// abortRun reports whether the run should be cancelled after the given number of failures.
+func abortRun(globalOptions *config.Config, failures int) bool {
+	return globalOptions.FailFast || (globalOptions.MaxFailures > 0 && failures >= globalOptions.MaxFailures)
+}
+
+func findTemplateSet(rootDir string) (*config.TemplateSet, error) {
+	return loadTemplateSet(path.Join(rootDir, config.GhConfigBaseDir))
+}
+
+// loadTemplateSet loads all templates of a directory with the layout of the .ghconfig directory.
+func loadTemplateSet(baseDir string) (*config.TemplateSet, error) {
+	workflowDirAbs := path.Join(baseDir, config.GhWorkflowDir)
+	templates, err := helper.FindWorkflows(workflowDirAbs)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowPatchesDirAbs := path.Join(baseDir, config.GhWorkflowDir, config.GhPatchesDir)
+	patches, err := helper.FindPatches(workflowPatchesDirAbs)
+	if err != nil {
+		return nil, err
+	}
+
+	dependabotTemplate, err := helper.FindDependabot(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &config.TemplateSet{
+		Workflows:  templates,
+		Patches:    patches,
+		Dependabot: dependabotTemplate,
+	}, nil
+}
+
+func selectRepositories(globalOptions *config.Config) ([]*github.Repository, []string, error) {
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = " Collecting all available repositories..."
+	s.Start()
+
+	repos, err := helper.FetchAllRepos(globalOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.Stop()
+
+	repos = activeRepositories(repos)
+
+	reposNames := []string{}
+
+	for _, repo := range repos {
+		reposNames = append(reposNames, *repo.FullName)
+	}
+
+	if hasSelectionOptions(globalOptions) {
+		targetRepos, err := filterRepositoryNames(globalOptions, reposNames)
 		if err != nil {
-			if resp.StatusCode == 404 {
-				log.Debugf("worklfow file %v doesn't exist on remote", remoteFilePath)
+			log.WithError(err).Error("could not select repositories")
+			return nil, nil, err
+		}
+		return repos, targetRepos, nil
+	}
+
+	targetRepos := []string{}
+	err = Multiselect(reposNames, &targetRepos)
+	if err != nil {
+		log.WithError(err).Error("could not create multi select for repository selection")
+		return nil, nil, err
+	}
+
+	return repos, targetRepos, nil
+}
+
+// activeRepositories removes all archived, forked, disabled and template repositories.
+func activeRepositories(repos []*github.Repository) []*github.Repository {
+	active := []*github.Repository{}
+	for _, repo := range repos {
+		switch {
+		case repo.GetArchived():
+			log.Debugf("skip archived repository %v", repo.GetFullName())
+		case repo.GetFork():
+			log.Debugf("skip forked repository %v", repo.GetFullName())
+		case repo.GetDisabled():
+			log.Debugf("skip disabled repository %v", repo.GetFullName())
+		case repo.GetIsTemplate():
+			log.Debugf("skip template repository %v", repo.GetFullName())
+		default:
+			active = append(active, repo)
+		}
+	}
+	return active
+}
+
+func hasSelectionOptions(globalOptions *config.Config) bool {
+	return globalOptions.SelectAll ||
+		len(globalOptions.IncludePatterns) > 0 ||
+		len(globalOptions.ExcludePatterns) > 0 ||
+		globalOptions.ReposFile != ""
+}
+
+// filterRepositoryNames selects repositories without a prompt. The repositories of the repos file
+// (or all repositories) are filtered by the include and exclude patterns.
+func filterRepositoryNames(globalOptions *config.Config, reposNames []string) ([]string, error) {
+	candidates := reposNames
+
+	if globalOptions.ReposFile != "" {
+		fileNames, err := helper.ReadRepositoryNames(globalOptions.ReposFile)
+		if err != nil {
+			return nil, err
+		}
+		candidates = []string{}
+		for _, name := range fileNames {
+			if !containsName(reposNames, name) {
+				log.Warnf("repository %v of %v was not found or is inactive", name, globalOptions.ReposFile)
+				continue
+			}
+			candidates = append(candidates, name)
+		}
+	}
+
+	targetRepos := []string{}
+	for _, name := range candidates {
+		if len(globalOptions.IncludePatterns) > 0 {
+			included, err := helper.MatchRepositoryName(globalOptions.IncludePatterns, name)
+			if err != nil {
+				return nil, err
+			}
+			if !included {
 				continue
 			}
-			log.WithError(err).Error("could not list workflow file")
-			return nil, err
 		}
-		fileResp, err := http.Get(content.GetDownloadURL())
+		excluded, err := helper.MatchRepositoryName(globalOptions.ExcludePatterns, name)
 		if err != nil {
-			log.WithError(err).Errorf("could not download file: %v", content.GetDownloadURL())
-			continue
+			return nil, err
 		}
-		if fileResp.StatusCode != 200 {
-			log.WithError(fmt.Errorf("file: %v", content.GetDownloadURL())).Error("could not download file")
+		if excluded {
 			continue
 		}
-		defer fileResp.Body.Close()
+		targetRepos = append(targetRepos, name)
+	}
+
+	return targetRepos, nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
 
-		data, err := ioutil.ReadAll(fileResp.Body)
+func newProgressBar(max int) *progressbar.ProgressBar {
+	return progressbar.NewOptions(max,
+		progressbar.OptionSetWriter(ansi.NewAnsiStdout()),
+		progressbar.OptionEnableColorCodes(true),
+		progressbar.OptionSetWidth(15),
+		progressbar.OptionSetDescription("Processing repositories..."),
+		progressbar.OptionSetTheme(progressbar.Theme{
+			Saucer:        "[green]=[reset]",
+			SaucerHead:    "[green]>[reset]",
+			SaucerPadding: " ",
+			BarStart:      "[",
+			BarEnd:        "]",
+		}))
+}
+
+// validateRepositoryFiles validates all files of the repository against the JSON schemas and records the schema errors.
+func validateRepositoryFiles(update *config.RepositoryUpdate) error {
+	for _, file := range update.Files {
+		schemaErrors, err := helper.ValidateRepositoryFile(file)
 		if err != nil {
-			log.WithError(err).Error("could not read file response")
+			log.WithField("repository", update.Repository.GetFullName()).
+				WithError(err).Errorf("could not validate %v", file.RepositoryUpdateOptions.DisplayName)
+			return err
+		}
+		update.SchemaErrors = append(update.SchemaErrors, schemaErrors...)
+	}
+	return nil
+}
+
+// syncRepository prepares, validates and pushes all files of a repository. The returned update
+// always carries the status of the repository.
+func syncRepository(globalOptions *config.Config, plan *repositoryPlan) *config.RepositoryUpdate {
+	ctx := log.WithFields(log.Fields{
+		"repository": plan.Repository.GetFullName(),
+	})
+
+	update := newRepositoryUpdate(globalOptions, plan)
+
+	if budgetExhausted(globalOptions) {
+		ctx.Warn("skip repository because the request budget is exhausted")
+		return skipRepository(update, "request budget is exhausted")
+	}
+
+	err := prepareRepositoryFiles(globalOptions, update, plan.TemplateSet)
+	if err != nil {
+		return failRepository(update, err)
+	}
+
+	err = validateRepositoryFiles(update)
+	if err != nil {
+		return failRepository(update, err)
+	}
+
+	if len(update.SchemaErrors) > 0 {
+		ctx.Warnf("skip repository because %d schema errors were found", len(update.SchemaErrors))
+		for _, schemaError := range update.SchemaErrors {
+			update.Errors = append(update.Errors, fmt.Sprintf("%v: %v: %v", schemaError.Filename, schemaError.Field, schemaError.Description))
+		}
+		update.Status = config.StatusSkipped
+		return update
+	}
+
+	if len(update.Conflicts) > 0 {
+		ctx.Warnf("skip repository because %d merge conflicts were found", len(update.Conflicts))
+		for _, conflict := range update.Conflicts {
+			update.Errors = append(update.Errors, fmt.Sprintf("%v: %v: changed in the template and the remote file", conflict.Filename, conflict.Path))
+		}
+		update.Status = config.StatusSkipped
+		return update
+	}
+
+	preparedFiles := update.Files
+	dropUnchangedFiles(update)
+
+	if len(update.Files) > 0 && update.State != nil {
+		// the templates of all files are recorded, including the files which are up to date
+		stateFile, err := helper.StateFile(update.State, preparedFiles)
+		if err != nil {
+			ctx.WithError(err).Error("could not create state file")
+			return failRepository(update, err)
+		}
+		if !helper.IsFileUnchanged(stateFile) {
+			update.Files = append(update.Files, stateFile)
+		}
+	}
+
+	if len(update.Files) == 0 {
+		if globalOptions.CreatePR && !globalOptions.DryRun {
+			// a PR of a previous run isn't needed anymore
+			pullRequestURL, err := helper.ClosePR(globalOptions, update.RepositoryOptions)
+			if err != nil {
+				ctx.WithError(err).Error("could not close obsolete PR")
+				return failRepository(update, err)
+			}
+			if pullRequestURL != "" {
+				ctx.Infof("closed obsolete PR %v", pullRequestURL)
+			}
+		}
+		update.Status = config.StatusUnchanged
+		return update
+	}
+
+	if !globalOptions.DryRun {
+		if globalOptions.CreatePR {
+			pullRequestURL, err := helper.CreatePR(globalOptions, update)
+			if err != nil {
+				ctx.WithError(err).Error("could not create PR with changes")
+				return failRepository(update, err)
+			}
+			update.PullRequestURL = pullRequestURL
+		} else {
+			// update files directly on the base branch
+			commit, err := helper.UpdateRepositoryFiles(globalOptions, update.RepositoryOptions, update.Files)
+			if err != nil {
+				ctx.WithError(err).Error("could not update files on remote")
+				return failRepository(update, err)
+			}
+			update.CommitSHA = commit.GetSHA()
+		}
+	}
+
+	update.Status = config.StatusUpdated
+	return update
+}
+
+func failRepository(update *config.RepositoryUpdate, err error) *config.RepositoryUpdate {
+	update.Status = config.StatusFailed
+	update.Errors = append(update.Errors, err.Error())
+	return update
+}
+
+func skipRepository(update *config.RepositoryUpdate, reason string) *config.RepositoryUpdate {
+	update.Status = config.StatusSkipped
+	update.Errors = append(update.Errors, reason)
+	return update
+}
+
+// advanceProgressBar advances the bar and shows the remaining budget of the rate limit.
+func advanceProgressBar(globalOptions *config.Config, bar *progressbar.ProgressBar) {
+	bar.Describe(progressDescription(globalOptions))
+	bar.Add(1)
+}
+
+// progressDescription shows the used request budget of `--max-requests`. Repositories which are started
+// after the budget is exhausted are skipped.
+func progressDescription(globalOptions *config.Config) string {
+	if globalOptions.RateLimit == nil || globalOptions.RateLimit.MaxRequests <= 0 {
+		return "Processing repositories..."
+	}
+	if budgetExhausted(globalOptions) {
+		return fmt.Sprintf("Processing repositories... (request budget of %d exhausted, skipping)", globalOptions.RateLimit.MaxRequests)
+	}
+	return fmt.Sprintf("Processing repositories... (requests %d/%d)", globalOptions.RateLimit.Requests(), globalOptions.RateLimit.MaxRequests)
+}
+
+func budgetExhausted(globalOptions *config.Config) bool {
+	return globalOptions.RateLimit != nil && globalOptions.RateLimit.Exhausted()
+}
+
+// branchGroup identifies the PRs of a command and template set. Only the open PR of the same group is
+// reused or closed, so that e.g a patch run doesn't touch the PR of a sync run.
+func branchGroup(globalOptions *config.Config, plan *repositoryPlan) string {
+	group := "sync"
+	if globalOptions.PatchOnly {
+		group = "patch"
+	}
+	if plan.Target != "" {
+		group += "-" + strings.Trim(invalidBranchChars.ReplaceAllString(strings.ToLower(plan.Target), "-"), "-")
+	}
+	return group
+}
+
+var invalidBranchChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+func newRepositoryUpdate(globalOptions *config.Config, plan *repositoryPlan) *config.RepositoryUpdate {
+	repo := plan.Repository
+
+	branchName := globalOptions.BaseBranch
+
+	if globalOptions.CreatePR {
+		branchName = fmt.Sprintf(config.BranchNamePattern, branchGroup(globalOptions, plan), globalOptions.Sid.MustGenerate())
+	}
+
+	updateOptions := &config.RepositoryUpdateOptions{
+		Owner:       *repo.GetOwner().Login,
+		Repo:        repo.GetName(),
+		BaseRef:     globalOptions.BaseBranch,
+		Branch:      branchName,
+		PRBranchRef: "refs/heads/" + branchName,
+	}
+
+	templateVars := config.TemplateVars{}
+	for k, v := range plan.TemplateVars {
+		templateVars[k] = v
+	}
+	templateVars["Repo"] = repo
+
+	return &config.RepositoryUpdate{
+		RepositoryOptions: updateOptions,
+		Repository:        repo,
+		TemplateVars:      templateVars,
+	}
+}
+
+// prepareRepositoryFiles collects all file updates of a repository. Errors are logged.
+func prepareRepositoryFiles(globalOptions *config.Config, update *config.RepositoryUpdate, templateSet *config.TemplateSet) error {
+	ctx := log.WithFields(log.Fields{
+		"repository": update.Repository.GetFullName(),
+	})
+
+	if globalOptions.PatchOnly {
+		files, err := preparePatches(globalOptions, update, templateSet.Patches)
+		if err != nil {
+			ctx.WithError(err).Error("could not prepare patch files")
+			return err
+		}
+		update.Files = append(update.Files, files...)
+		return nil
+	}
+
+	if globalOptions.ThreeWayMerge {
+		state, err := helper.GetState(globalOptions, update.RepositoryOptions)
+		if err != nil {
+			ctx.WithError(err).Error("could not read state file")
+			return err
+		}
+		update.State = state
+	}
+
+	files, err := prepareWorkflows(globalOptions, update, templateSet.Workflows)
+	if err != nil {
+		ctx.WithError(err).Error("could not prepare workflow files")
+		return err
+	}
+	update.Files = append(update.Files, files...)
+
+	if templateSet.Dependabot != nil {
+		fileUpdate, err := prepareDependabot(globalOptions, update, templateSet.Dependabot)
+		if err != nil {
+			ctx.WithError(err).Error("could not prepare dependabot file")
+			return err
+		}
+		if fileUpdate != nil {
+			update.Files = append(update.Files, fileUpdate)
+		}
+	}
+
+	return nil
+}
+
+// dropUnchangedFiles removes all files whose content is semantically equal to the remote file.
+func dropUnchangedFiles(update *config.RepositoryUpdate) {
+	files := []*config.RepositoryFileUpdate{}
+	for _, file := range update.Files {
+		if helper.IsFileUnchanged(file) {
+			log.Debugf("file %v of %v is up to date", file.RepositoryUpdateOptions.Path, update.Repository.GetFullName())
 			continue
 		}
+		files = append(files, file)
+	}
+	update.Files = files
+}
 
-		t := gh.GithubWorkflow{}
-		err = yaml.Unmarshal(data, &t)
+func preparePatches(opts *config.Config, update *config.RepositoryUpdate, patches []*config.PatchData) ([]*config.RepositoryFileUpdate, error) {
+	files := []*config.RepositoryFileUpdate{}
+
+	for _, patch := range patches {
+		remoteFilePath := path.Join(config.GithubConfigBaseDir, "workflows", patch.Filename)
+		remoteFile, resp, err := helper.GetRemoteFile(opts, update.RepositoryOptions, remoteFilePath)
+		if err != nil {
+			if resp != nil && resp.StatusCode == 404 {
+				log.Debugf("worklfow file %v doesn't exist on remote", remoteFilePath)
+				continue
+			}
+			log.WithError(err).Error("could not fetch workflow file")
+			return nil, fmt.Errorf("%v: %w", patch.Filename, err)
+		}
+		content := remoteFile.Content
+		data := remoteFile.Data
+
+		remoteFileData := data
+
+		// patch the plain document so that keys which are unknown to the model are preserved
+		var remoteWorkflow interface{}
+		err = yaml.Unmarshal(data, &remoteWorkflow)
 		if err != nil {
 			log.WithError(err).Error("could not unmarshal workflow")
-			continue
+			return nil, fmt.Errorf("%v: %w", patch.Filename, err)
 		}
 
-		repositoryFileJSON, err := json.Marshal(t)
+		repositoryFileJSON, err := json.Marshal(remoteWorkflow)
 		if err != nil {
 			log.WithError(err).Error("could not convert yaml to json")
-			continue
+			return nil, fmt.Errorf("%v: %w", patch.Filename, err)
 		}
 
 		y, err := yaml.Marshal(patch)
 		if err != nil {
 			log.WithError(err).Error("could not marshal patch")
-			return nil, err
+			return nil, fmt.Errorf("%v: %w", patch.Filename, err)
 		}
 
 		jsonPatchData, err := helper.ExecuteTemplate(patch.Filename, string(y), update.TemplateVars)
 		if err != nil {
 			log.WithError(err).Error("could not template")
-			continue
+			return nil, fmt.Errorf("%v: %w", patch.Filename, err)
 		}
 
 		newPatchData := config.PatchData{}
 		err = yaml.Unmarshal(jsonPatchData.Bytes(), &newPatchData)
 		if err != nil {
 			log.WithError(err).Error("could not unmarshal template")
-			continue
+			return nil, fmt.Errorf("%v: %w", patch.Filename, err)
 		}
 
 		templatedJSONPatchFile, err := json.Marshal(newPatchData.Patch)
 		if err != nil {
 			log.WithError(err).Error("could not marshal templated patch file to json")
-			continue
+			return nil, fmt.Errorf("%v: %w", patch.Filename, err)
 		}
 
 		jsonPatch, err := jsonpatch.DecodePatch(templatedJSONPatchFile)
 		if err != nil {
 			log.WithError(err).Error("invalid patch file")
-			continue
+			return nil, fmt.Errorf("%v: %w", patch.Filename, err)
 		}
 
 		data, err = jsonPatch.Apply(repositoryFileJSON)
 		if err != nil {
 			log.WithError(err).Error("could not apply patch")
-			continue
+			return nil, fmt.Errorf("%v: %w", patch.Filename, err)
 		}
 
-		t = gh.GithubWorkflow{}
+		t := gh.GithubWorkflow{}
 		err = yaml.Unmarshal(data, &t)
 		if err != nil {
 			log.WithError(err).Error("could not unmarshal patched workflow")
-			continue
+			return nil, fmt.Errorf("%v: %w", patch.Filename, err)
 		}
-		repositoryFileJSON, err = yaml.Marshal(&t)
+		repositoryFileJSON, err = marshalRemoteFile(opts, remoteFileData, &t)
 		if err != nil {
 			log.WithError(err).Error("could not marshal patched workflow")
-			continue
+			return nil, fmt.Errorf("%v: %w", patch.Filename, err)
 		}
 
 		file := &config.RepositoryFileUpdate{}
 		file.RepositoryUpdateOptions = &config.RepositoryFileUpdateOptions{}
 		file.RepositoryUpdateOptions.Filename = content.GetName()
 		file.RepositoryUpdateOptions.DisplayName = content.GetName() + " (patched)"
+		file.RepositoryUpdateOptions.Action = config.FilePatched
 		file.Workflow = &t
 		file.RepositoryUpdateOptions.FileContent = &repositoryFileJSON
+		file.RepositoryUpdateOptions.RemoteFileContent = &remoteFileData
 		file.RepositoryUpdateOptions.Path = content.GetPath()
 		file.RepositoryUpdateOptions.SHA = content.GetSHA()
 
@@ -374,8 +836,8 @@ func prepareWorkflows(opts *config.Config, update *config.RepositoryUpdate, temp
 
 	files := []*config.RepositoryFileUpdate{}
 
-	if err != nil && resp.StatusCode != 404 {
-		log.Debugf("workflow directory %v doesn't exist on remote", directory)
+	if err != nil && (resp == nil || resp.StatusCode != 404) {
+		log.WithError(err).Errorf("could not list workflow directory %v", directory)
 		return nil, err
 	}
 
@@ -385,13 +847,13 @@ func prepareWorkflows(opts *config.Config, update *config.RepositoryUpdate, temp
 		locaTemplateData, err := yaml.Marshal(workflowTemplate.Workflow)
 		if err != nil {
 			log.WithError(err).Error("could not marshal template")
-			continue
+			return nil, fmt.Errorf("%v: %w", workflowTemplate.Filename, err)
 		}
 
 		bytesCache, err := helper.ExecuteTemplate(workflowTemplate.Filename, string(locaTemplateData), update.TemplateVars)
 		if err != nil {
 			log.WithError(err).Error("could not template")
-			continue
+			return nil, fmt.Errorf("%v: %w", workflowTemplate.Filename, err)
 		}
 
 		localTemplate := gh.GithubWorkflow{}
@@ -399,70 +861,72 @@ func prepareWorkflows(opts *config.Config, update *config.RepositoryUpdate, temp
 		err = yaml.Unmarshal(templateBytes, &localTemplate)
 		if err != nil {
 			log.WithError(err).Error("could unmarshal template")
-			continue
+			return nil, fmt.Errorf("%v: %w", workflowTemplate.Filename, err)
+		}
+
+		var appliedTemplateData []byte
+		if opts.ThreeWayMerge {
+			appliedTemplateData, err = appliedWorkflow(templateBytes)
+			if err != nil {
+				log.WithError(err).Errorf("invalid template %v", workflowTemplate.Filename)
+				return nil, fmt.Errorf("%v: %w", workflowTemplate.Filename, err)
+			}
 		}
 
 		for _, content := range dirContent {
 			if content.GetName() == workflowTemplate.Filename {
-				content, _, resp, err := opts.GithubClient.Repositories.GetContents(
-					opts.Context,
-					update.RepositoryOptions.Owner,
-					update.RepositoryOptions.Repo,
-					workflowTemplate.RepositoryPath,
-					&github.RepositoryContentGetOptions{
-						Ref: update.RepositoryOptions.BaseRef,
-					},
-				)
+				remoteFile, resp, err := helper.GetRemoteFile(opts, update.RepositoryOptions, workflowTemplate.RepositoryPath)
 				if err != nil {
-					if resp.StatusCode == 404 {
+					if resp != nil && resp.StatusCode == 404 {
 						log.Debugf("worklfow file %v doesn't exist anymore on remote", workflowTemplate.RepositoryPath)
 						continue
 					}
-					log.WithError(err).Error("could not list workflow file")
-					continue
-				}
-
-				fileResp, err := http.Get(content.GetDownloadURL())
-				if err != nil {
-					log.WithError(err).Errorf("could not download file: %v", content.GetDownloadURL())
-					continue
-				}
-				if fileResp.StatusCode != 200 {
-					return nil, fmt.Errorf("could not download: %v", content.GetDownloadURL())
-				}
-				defer fileResp.Body.Close()
-
-				remoteFileData, err := ioutil.ReadAll(fileResp.Body)
-				if err != nil {
-					log.WithError(err).Error("could not read file response")
-					continue
+					log.WithError(err).Error("could not fetch workflow file")
+					return nil, fmt.Errorf("%v: %w", workflowTemplate.Filename, err)
 				}
+				content := remoteFile.Content
+				remoteFileData := remoteFile.Data
 
+				// the remote file is never replaced by the template when it can't be merged
 				remoteTemplate := gh.GithubWorkflow{}
 				err = yaml.Unmarshal(remoteFileData, &remoteTemplate)
 				if err != nil {
-					log.WithError(err).Error("could not unmarshal template")
-					continue
+					log.WithError(err).Errorf("could not unmarshal remote workflow %v", workflowTemplate.RepositoryPath)
+					return nil, fmt.Errorf("could not unmarshal remote workflow %v: %w", workflowTemplate.RepositoryPath, err)
 				}
 
-				err = gh.MergeWorkflow(&remoteTemplate, localTemplate)
-				if err != nil {
-					log.WithError(err).Error("could not merge template")
-					continue
+				if base, ok := stateBase(update, workflowTemplate.RepositoryPath); ok {
+					mergedTemplate := gh.GithubWorkflow{}
+					conflicts, err := helper.ThreeWayMerge(base, remoteTemplate, localTemplate, &mergedTemplate)
+					if err != nil {
+						log.WithError(err).Error("could not merge template")
+						return nil, fmt.Errorf("%v: %w", workflowTemplate.Filename, err)
+					}
+					addMergeConflicts(update, workflowTemplate.RepositoryPath, conflicts)
+					remoteTemplate = mergedTemplate
+				} else {
+					err = gh.MergeWorkflow(&remoteTemplate, localTemplate)
+					if err != nil {
+						log.WithError(err).Error("could not merge template")
+						return nil, fmt.Errorf("%v: %w", workflowTemplate.Filename, err)
+					}
 				}
 
-				output, err := yaml.Marshal(remoteTemplate)
+				output, err := marshalRemoteFile(opts, remoteFileData, remoteTemplate)
 				if err != nil {
 					log.WithError(err).Error("could not marshal template")
-					continue
+					return nil, fmt.Errorf("%v: %w", workflowTemplate.Filename, err)
 				}
 
 				file = &config.RepositoryFileUpdate{}
 				file.RepositoryUpdateOptions = &config.RepositoryFileUpdateOptions{}
 				file.RepositoryUpdateOptions.Filename = content.GetName()
 				file.RepositoryUpdateOptions.DisplayName = file.RepositoryUpdateOptions.Filename
+				file.RepositoryUpdateOptions.Action = config.FileMerged
+				file.RepositoryUpdateOptions.Template = appliedTemplateData
 				file.Workflow = &remoteTemplate
 				file.RepositoryUpdateOptions.FileContent = &output
+				file.RepositoryUpdateOptions.RemoteFileContent = &remoteFileData
 				file.RepositoryUpdateOptions.Path = content.GetPath()
 				file.RepositoryUpdateOptions.SHA = content.GetSHA()
 				files = append(files, file)
@@ -470,10 +934,27 @@ func prepareWorkflows(opts *config.Config, update *config.RepositoryUpdate, temp
 			}
 		}
 		if file == nil {
+			if _, ok := stateBase(update, workflowTemplate.RepositoryPath); ok {
+				log.WithField("repository", update.Repository.GetFullName()).
+					Warnf("workflow file %v was deleted in the repository and isn't created again", workflowTemplate.RepositoryPath)
+				continue
+			}
+			if common.HasDirectives(templateBytes) {
+				// the remote file doesn't exist, there is nothing to merge
+				localTemplate.Merge = nil
+				gh.RemoveDeleteMarkers(&localTemplate)
+				templateBytes, err = yaml.Marshal(localTemplate)
+				if err != nil {
+					log.WithError(err).Error("could not marshal template")
+					return nil, fmt.Errorf("%v: %w", workflowTemplate.Filename, err)
+				}
+			}
 			file = &config.RepositoryFileUpdate{}
 			file.RepositoryUpdateOptions = &config.RepositoryFileUpdateOptions{}
 			file.RepositoryUpdateOptions.Filename = workflowTemplate.Filename
 			file.RepositoryUpdateOptions.DisplayName = workflowTemplate.Filename
+			file.RepositoryUpdateOptions.Action = config.FileCreated
+			file.RepositoryUpdateOptions.Template = appliedTemplateData
 			file.Workflow = &localTemplate
 			file.RepositoryUpdateOptions.FileContent = &templateBytes
 			file.RepositoryUpdateOptions.Path = workflowTemplate.RepositoryPath
@@ -505,47 +986,51 @@ func prepareDependabot(
 		return nil, err
 	}
 
+	var appliedTemplateData []byte
+	if opts.ThreeWayMerge {
+		appliedTemplateData, err = appliedDependabot(localYAMLData)
+		if err != nil {
+			log.WithError(err).Errorf("invalid template %v", dependabotTemplate.Filename)
+			return nil, fmt.Errorf("%v: %w", dependabotTemplate.Filename, err)
+		}
+	}
+
 	file := &config.RepositoryFileUpdate{}
 	remoteFilePath := path.Join(config.GithubConfigBaseDir, dependabotTemplate.Filename)
-	content, _, resp, err := opts.GithubClient.Repositories.GetContents(
-		opts.Context,
-		update.RepositoryOptions.Owner,
-		update.RepositoryOptions.Repo,
-		remoteFilePath,
-		&github.RepositoryContentGetOptions{
-			Ref: update.RepositoryOptions.BaseRef,
-		},
-	)
-
+	remoteFile, resp, err := helper.GetRemoteFile(opts, update.RepositoryOptions, remoteFilePath)
 	if err != nil {
-		if resp.StatusCode == 404 {
+		if resp != nil && resp.StatusCode == 404 {
 			log.Debugf("dependabot file %v doesn't exist on remote", remoteFilePath)
+			if _, ok := stateBase(update, remoteFilePath); ok {
+				log.WithField("repository", update.Repository.GetFullName()).
+					Warnf("dependabot file %v was deleted in the repository and isn't created again", remoteFilePath)
+				return nil, nil
+			}
+			if common.HasDirectives(localYAMLData) {
+				// there is nothing to merge
+				localTemplate.Merge = nil
+				dependabot.RemoveDeleteMarkers(&localTemplate)
+				localYAMLData, err = yaml.Marshal(localTemplate)
+				if err != nil {
+					log.WithError(err).Error("could not marshal template")
+					return nil, err
+				}
+			}
 			file.RepositoryUpdateOptions = &config.RepositoryFileUpdateOptions{}
 			file.RepositoryUpdateOptions.Filename = dependabotTemplate.Filename
 			file.RepositoryUpdateOptions.DisplayName = dependabotTemplate.Filename
+			file.RepositoryUpdateOptions.Action = config.FileCreated
+			file.RepositoryUpdateOptions.Template = appliedTemplateData
+			file.Dependabot = &localTemplate
 			file.RepositoryUpdateOptions.FileContent = &localYAMLData
 			file.RepositoryUpdateOptions.Path = remoteFilePath
 			return file, nil
 		}
-		log.WithError(err).Error("could not list health file")
-		return nil, err
-	}
-	url := content.GetDownloadURL()
-	fileResp, err := http.Get(url)
-	if err != nil {
-		log.WithError(err).Errorf("could not download file: %v", content.GetDownloadURL())
-		return nil, err
-	}
-	if fileResp.StatusCode != 200 {
-		return nil, fmt.Errorf("could not download: %v", content.GetDownloadURL())
-	}
-	defer fileResp.Body.Close()
-
-	remoteFileData, err := ioutil.ReadAll(fileResp.Body)
-	if err != nil {
-		log.WithError(err).Error("could not read file response")
+		log.WithError(err).Error("could not fetch health file")
 		return nil, err
 	}
+	content := remoteFile.Content
+	remoteFileData := remoteFile.Data
 
 	remoteTemplate := dependabot.GithubDependabot{}
 	err = yaml.Unmarshal(remoteFileData, &remoteTemplate)
@@ -554,13 +1039,24 @@ func prepareDependabot(
 		return nil, err
 	}
 
-	err = dependabot.MergeDependabot(&remoteTemplate, localTemplate)
-	if err != nil {
-		log.WithError(err).Error("could merge dependabot template")
-		return nil, err
+	if base, ok := stateBase(update, remoteFilePath); ok {
+		mergedTemplate := dependabot.GithubDependabot{}
+		conflicts, err := helper.ThreeWayMerge(base, remoteTemplate, localTemplate, &mergedTemplate)
+		if err != nil {
+			log.WithError(err).Error("could merge dependabot template")
+			return nil, err
+		}
+		addMergeConflicts(update, remoteFilePath, conflicts)
+		remoteTemplate = mergedTemplate
+	} else {
+		err = dependabot.MergeDependabot(&remoteTemplate, localTemplate)
+		if err != nil {
+			log.WithError(err).Error("could merge dependabot template")
+			return nil, err
+		}
 	}
 
-	output, err := yaml.Marshal(remoteTemplate)
+	output, err := marshalRemoteFile(opts, remoteFileData, remoteTemplate)
 	if err != nil {
 		log.WithError(err).Error("could not marshal template")
 		return nil, err
@@ -569,14 +1065,90 @@ func prepareDependabot(
 	file.RepositoryUpdateOptions = &config.RepositoryFileUpdateOptions{}
 	file.RepositoryUpdateOptions.Filename = content.GetName()
 	file.RepositoryUpdateOptions.DisplayName = file.RepositoryUpdateOptions.Filename
+	file.RepositoryUpdateOptions.Action = config.FileMerged
+	file.RepositoryUpdateOptions.Template = appliedTemplateData
 	file.Dependabot = &remoteTemplate
 	file.RepositoryUpdateOptions.FileContent = &output
+	file.RepositoryUpdateOptions.RemoteFileContent = &remoteFileData
 	file.RepositoryUpdateOptions.Path = content.GetPath()
 	file.RepositoryUpdateOptions.SHA = content.GetSHA()
 
 	return file, nil
 }
 
+// stateBase returns the last applied template of the file which is the common ancestor of the three-way merge.
+func stateBase(update *config.RepositoryUpdate, filePath string) ([]byte, bool) {
+	if update.State == nil {
+		return nil, false
+	}
+	base, ok := update.State.Files[filePath]
+	return []byte(base), ok
+}
+
+func addMergeConflicts(update *config.RepositoryUpdate, filePath string, conflicts []string) {
+	for _, conflict := range conflicts {
+		log.WithField("repository", update.Repository.GetFullName()).Warnf("merge conflict in %v at %v", filePath, conflict)
+		update.Conflicts = append(update.Conflicts, &config.MergeConflict{Filename: filePath, Path: conflict})
+	}
+}
+
+// errThreeWayDirectives is returned for templates with merge directives when the three-way merge is used.
+// Values which are removed from the template are removed from the remote file instead.
+var errThreeWayDirectives = errors.New("$merge and $delete can't be used with the three-way merge, remove the values from the template instead")
+
+// appliedWorkflow returns the local template as it's recorded in the state file.
+func appliedWorkflow(templateBytes []byte) ([]byte, error) {
+	t := gh.GithubWorkflow{}
+	err := yaml.Unmarshal(templateBytes, &t)
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	gh.RemoveDeleteMarkers(&t)
+	withoutMarkers, err := yaml.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	if t.Merge != nil || !bytes.Equal(data, withoutMarkers) {
+		return nil, errThreeWayDirectives
+	}
+	return data, nil
+}
+
+// appliedDependabot returns the local template as it's recorded in the state file.
+func appliedDependabot(templateBytes []byte) ([]byte, error) {
+	t := dependabot.GithubDependabot{}
+	err := yaml.Unmarshal(templateBytes, &t)
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	dependabot.RemoveDeleteMarkers(&t)
+	withoutMarkers, err := yaml.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	if t.Merge != nil || !bytes.Equal(data, withoutMarkers) {
+		return nil, errThreeWayDirectives
+	}
+	return data, nil
+}
+
+// marshalRemoteFile encodes the merged file. When the format should be preserved
+// only the changed nodes of the remote file are updated, otherwise only the key order is kept.
+func marshalRemoteFile(opts *config.Config, remoteFileData []byte, value interface{}) ([]byte, error) {
+	if opts.PreserveFormat {
+		return common.MarshalWithFormat(remoteFileData, value)
+	}
+	return common.MarshalWithKeyOrder(remoteFileData, value)
+}
+
 func getRepoByName(repos []*github.Repository, name string) *github.Repository {
 	for _, repo := range repos {
 		if name == *repo.FullName {
@@ -1,14 +1,21 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"ghconfig/internal/config"
 	"ghconfig/internal/dependabot"
 	gh "ghconfig/internal/github"
+	"ghconfig/internal/ratelimit"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/apex/log"
@@ -57,7 +64,7 @@ func TestSync_WorkflowNotExistOnRemote(t *testing.T) {
 	})
 
 	args := &createRefRequest{
-		Ref: github.String("refs/heads/ghconfig/workflows/fixed_id"),
+		Ref: github.String("refs/heads/ghconfig/workflows/sync/fixed_id"),
 		SHA: github.String("aa218f56b14c9653891f9e74264a383fa43fefbd"),
 	}
 	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
@@ -70,8 +77,8 @@ func TestSync_WorkflowNotExistOnRemote(t *testing.T) {
 		}
 		fmt.Fprint(w, `
 		  {
-		    "ref": "refs/heads/ghconfig/workflows/fixed_id",
-		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/fixed_id",
+		    "ref": "refs/heads/ghconfig/workflows/sync/fixed_id",
+		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/sync/fixed_id",
 		    "object": {
 		      "type": "commit",
 		      "sha": "aa218f56b14c9653891f9e74264a383fa43fefbd",
@@ -81,12 +88,18 @@ func TestSync_WorkflowNotExistOnRemote(t *testing.T) {
 	})
 	input := &github.NewPullRequest{
 		Title: github.String("Synchronize (.github) configurations by ghconfig"),
-		Head:  github.String("ghconfig/workflows/fixed_id"),
+		Head:  github.String("ghconfig/workflows/sync/fixed_id"),
 		Base:  github.String("master"),
+		Body:  github.String(pullRequestBody("- `.github/workflows/ci.yaml` (created, +39 -0)")),
 		Draft: github.Bool(true),
 	}
 
 	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			// no open PR of ghconfig
+			fmt.Fprint(w, `[]`)
+			return
+		}
 		v := new(github.NewPullRequest)
 		json.NewDecoder(r.Body).Decode(v)
 
@@ -107,29 +120,13 @@ func TestSync_WorkflowNotExistOnRemote(t *testing.T) {
 				"path": ".github/workflows/ci.yaml",
 				"download_url": "`+serverURL+baseURLPath+`/download/.github/workflows/ci.yaml"
 			  }`)
-		case "PUT":
-			rr := readRepositoryContentFileOptions(r.Body)
-			workflow := gh.GithubWorkflow{}
-			yaml.Unmarshal(rr.Content, &workflow)
-
-			assert.Equal(t, workflow.Name, "Node CI")
-
-			fmt.Fprint(w, `
-			{
-				"content":{
-					"name":"CI"
-				},
-				"commit":{
-					"message":"m",
-					"sha":"f5f369044773ff9c6383c087466d12adb6fa0828",
-					"html_url": "https://github.com/o/r/blob/master/.github/workflows/ci.yaml"
-				}
-			}`)
 		default:
-			t.Errorf("Request method: %v, want %v", r.Method, "PUT or GET")
+			t.Errorf("Request method: %v, want %v", r.Method, "GET")
 		}
 	})
 
+	commit := handleCommit(t, mux, "ghconfig/workflows/sync/fixed_id")
+
 	ctx := context.Background()
 	sid := testIDGenerator{}
 
@@ -155,6 +152,12 @@ func TestSync_WorkflowNotExistOnRemote(t *testing.T) {
 		t.Fatalf("could not execute command, %v", err)
 	}
 
+	assert.Len(t, commit.Blobs, 1)
+	workflow := gh.GithubWorkflow{}
+	yaml.Unmarshal(commit.Blobs[0], &workflow)
+
+	assert.Equal(t, workflow.Name, "Node CI")
+
 	for _, entry := range h.Entries {
 		if entry.Level >= log.ErrorLevel {
 			t.Errorf("stderr should be empty, error: %v", entry)
@@ -201,7 +204,7 @@ func TestSync_WorkflowCustomCommitMsg(t *testing.T) {
 	})
 
 	args := &createRefRequest{
-		Ref: github.String("refs/heads/ghconfig/workflows/fixed_id"),
+		Ref: github.String("refs/heads/ghconfig/workflows/sync/fixed_id"),
 		SHA: github.String("aa218f56b14c9653891f9e74264a383fa43fefbd"),
 	}
 	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
@@ -214,8 +217,8 @@ func TestSync_WorkflowCustomCommitMsg(t *testing.T) {
 		}
 		fmt.Fprint(w, `
 		  {
-		    "ref": "refs/heads/ghconfig/workflows/fixed_id",
-		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/fixed_id",
+		    "ref": "refs/heads/ghconfig/workflows/sync/fixed_id",
+		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/sync/fixed_id",
 		    "object": {
 		      "type": "commit",
 		      "sha": "aa218f56b14c9653891f9e74264a383fa43fefbd",
@@ -225,12 +228,18 @@ func TestSync_WorkflowCustomCommitMsg(t *testing.T) {
 	})
 	input := &github.NewPullRequest{
 		Title: github.String("Synchronize (.github) configurations by ghconfig"),
-		Head:  github.String("ghconfig/workflows/fixed_id"),
+		Head:  github.String("ghconfig/workflows/sync/fixed_id"),
 		Base:  github.String("master"),
+		Body:  github.String(pullRequestBody("- `.github/workflows/ci.yaml` (created, +39 -0)")),
 		Draft: github.Bool(true),
 	}
 
 	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			// no open PR of ghconfig
+			fmt.Fprint(w, `[]`)
+			return
+		}
 		v := new(github.NewPullRequest)
 		json.NewDecoder(r.Body).Decode(v)
 
@@ -251,30 +260,13 @@ func TestSync_WorkflowCustomCommitMsg(t *testing.T) {
 				"path": ".github/workflows/ci.yaml",
 				"download_url": "`+serverURL+baseURLPath+`/download/.github/workflows/ci.yaml"
 			  }`)
-		case "PUT":
-			rr := readRepositoryContentFileOptions(r.Body)
-			workflow := gh.GithubWorkflow{}
-			yaml.Unmarshal(rr.Content, &workflow)
-
-			assert.Equal(t, *rr.Message, "custom commit message")
-			assert.Equal(t, workflow.Name, "Node CI")
-
-			fmt.Fprint(w, `
-			{
-				"content":{
-					"name":"CI"
-				},
-				"commit":{
-					"message":"m",
-					"sha":"f5f369044773ff9c6383c087466d12adb6fa0828",
-					"html_url": "https://github.com/o/r/blob/master/.github/workflows/ci.yaml"
-				}
-			}`)
 		default:
-			t.Errorf("Request method: %v, want %v", r.Method, "PUT or GET")
+			t.Errorf("Request method: %v, want %v", r.Method, "GET")
 		}
 	})
 
+	commit := handleCommit(t, mux, "ghconfig/workflows/sync/fixed_id")
+
 	ctx := context.Background()
 	sid := testIDGenerator{}
 
@@ -301,6 +293,13 @@ func TestSync_WorkflowCustomCommitMsg(t *testing.T) {
 		t.Fatalf("could not execute command, %v", err)
 	}
 
+	assert.Len(t, commit.Blobs, 1)
+	workflow := gh.GithubWorkflow{}
+	yaml.Unmarshal(commit.Blobs[0], &workflow)
+
+	assert.Equal(t, commit.Message, "custom commit message")
+	assert.Equal(t, workflow.Name, "Node CI")
+
 	for _, entry := range h.Entries {
 		if entry.Level >= log.ErrorLevel {
 			t.Errorf("stderr should be empty, error: %v", entry)
@@ -309,7 +308,7 @@ func TestSync_WorkflowCustomCommitMsg(t *testing.T) {
 }
 
 func TestSync_WorkflowExistOnRemote(t *testing.T) {
-	client, mux, serverURL, teardown := setup()
+	client, mux, _, teardown := setup()
 	defer teardown()
 
 	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
@@ -354,7 +353,7 @@ func TestSync_WorkflowExistOnRemote(t *testing.T) {
 	})
 
 	args := &createRefRequest{
-		Ref: github.String("refs/heads/ghconfig/workflows/fixed_id"),
+		Ref: github.String("refs/heads/ghconfig/workflows/sync/fixed_id"),
 		SHA: github.String("aa218f56b14c9653891f9e74264a383fa43fefbd"),
 	}
 	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
@@ -367,8 +366,8 @@ func TestSync_WorkflowExistOnRemote(t *testing.T) {
 		}
 		fmt.Fprint(w, `
 		  {
-		    "ref": "refs/heads/ghconfig/workflows/fixed_id",
-		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/fixed_id",
+		    "ref": "refs/heads/ghconfig/workflows/sync/fixed_id",
+		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/sync/fixed_id",
 		    "object": {
 		      "type": "commit",
 		      "sha": "aa218f56b14c9653891f9e74264a383fa43fefbd",
@@ -378,12 +377,18 @@ func TestSync_WorkflowExistOnRemote(t *testing.T) {
 	})
 	input := &github.NewPullRequest{
 		Title: github.String("Synchronize (.github) configurations by ghconfig"),
-		Head:  github.String("ghconfig/workflows/fixed_id"),
+		Head:  github.String("ghconfig/workflows/sync/fixed_id"),
 		Base:  github.String("master"),
+		Body:  github.String(pullRequestBody("- `.github/workflows/ci.yaml` (merged, +32 -2)")),
 		Draft: github.Bool(true),
 	}
 
 	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			// no open PR of ghconfig
+			fmt.Fprint(w, `[]`)
+			return
+		}
 		v := new(github.NewPullRequest)
 		json.NewDecoder(r.Body).Decode(v)
 
@@ -395,104 +400,23 @@ func TestSync_WorkflowExistOnRemote(t *testing.T) {
 		fmt.Fprint(w, `{"number":1, "html_url": "https://github.com/o/r/pull/20"}`)
 	})
 
-	mux.HandleFunc("/repos/o/r/contents/.github/workflows/ci.yaml", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			fmt.Fprint(w, `{
-				"type": "file",
-				"name": "f",
-				"path": ".github/workflows/ci.yaml",
-				"download_url": "`+serverURL+baseURLPath+`/download/.github/workflows/ci.yaml"
-			  }`)
-		case "PUT":
-			rr := readRepositoryContentFileOptions(r.Body)
-			workflow := gh.GithubWorkflow{}
-			yaml.Unmarshal(rr.Content, &workflow)
-
-			output := gh.GithubWorkflow{
-				On: gh.On{
-					Push: gh.Push{
-						PathsIgnore: []string{"**.md", "docs/**"},
-					},
-					PullRequest: gh.PullRequest{
-						PathsIgnore: []string{"**.md", "docs/**"},
-					},
-				},
-				Env: map[string]string{
-					"A":   "o/r",
-					"CI":  "true",
-					"foo": "bar",
-				},
-				Name: "Node CI",
-				Jobs: map[string]*gh.Job{
-					"build": {
-						Name:   "Node ${{ matrix.node-version }}",
-						RunsOn: "${{ matrix.os }}",
-						Needs:  gh.StringArray{"a"},
-						Steps: []*gh.Step{
-							{Uses: "actions/checkout@v2"},
-							{
-								Name: "Use Node.js ${{ matrix.node-version }}",
-								Uses: "actions/setup-node@v1",
-								With: map[string]string{
-									"node-version": "${{ matrix.node-version }}",
-								},
-							},
-							{
-								Name: "install",
-								Run:  "yarn install\n",
-								With: map[string]string{
-									"a": "b",
-								},
-							},
-
-							{Name: "test", Run: "yarn test"},
-						},
-						Strategy: gh.Strategy{
-							Matrix: gh.Matrix{
-								"os":           []gh.MatrixValue{"ubuntu-latest"},
-								"node-version": []gh.MatrixValue{"11.x", "12.x", "14.x"},
-							},
-						},
-					},
-				},
-			}
-
-			assert.EqualValues(t, workflow, output)
-
-			fmt.Fprint(w, `
-			{
-				"content":{
-					"name":"CI"
+	remoteFile, _ := yaml.Marshal(&gh.GithubWorkflow{
+		Name: "patch",
+		Env: map[string]interface{}{
+			"foo": "bar",
+		},
+		Jobs: map[string]*gh.Job{
+			"build": {
+				Name: "Node ${{ matrix.node-version }}",
+				Steps: []*gh.Step{
+					{Name: "install", Run: "npm install", With: map[string]interface{}{"a": "b"}},
 				},
-				"commit":{
-					"message":"m",
-					"sha":"f5f369044773ff9c6383c087466d12adb6fa0828",
-					"html_url": "https://github.com/o/r/blob/master/.github/workflows/ci.yaml"
-				}
-			}`)
-		default:
-			t.Errorf("Request method: %v, want %v", r.Method, "PUT or GET")
-		}
-	})
-	mux.HandleFunc("/download/.github/workflows/ci.yaml", func(w http.ResponseWriter, r *http.Request) {
-		testMethod(t, r, "GET")
-		bytes, _ := yaml.Marshal(&gh.GithubWorkflow{
-			Name: "patch",
-			Env: map[string]string{
-				"foo": "bar",
 			},
-			Jobs: map[string]*gh.Job{
-				"build": {
-					Name: "Node ${{ matrix.node-version }}",
-					Steps: []*gh.Step{
-						{Name: "install", Run: "npm install", With: map[string]string{"a": "b"}},
-					},
-				},
-			},
-		})
-		fmt.Fprint(w, string(bytes))
+		},
 	})
+	handleRepositoryFile(t, mux, "o/r", ".github/workflows/ci.yaml", remoteFile)
+
+	commit := handleCommit(t, mux, "ghconfig/workflows/sync/fixed_id")
 
 	ctx := context.Background()
 	sid := testIDGenerator{}
@@ -519,6 +443,62 @@ func TestSync_WorkflowExistOnRemote(t *testing.T) {
 		t.Fatalf("could not execute command, %v", err)
 	}
 
+	assert.Len(t, commit.Blobs, 1)
+	workflow := gh.GithubWorkflow{}
+	yaml.Unmarshal(commit.Blobs[0], &workflow)
+
+	output := gh.GithubWorkflow{
+		On: gh.On{
+			Push: &gh.Push{
+				PathsIgnore: []string{"**.md", "docs/**"},
+			},
+			PullRequest: &gh.PullRequest{
+				PathsIgnore: []string{"**.md", "docs/**"},
+			},
+		},
+		Env: map[string]interface{}{
+			"A":   "o/r",
+			"CI":  true,
+			"foo": "bar",
+		},
+		Name: "Node CI",
+		Jobs: map[string]*gh.Job{
+			"build": {
+				Name:   "Node ${{ matrix.node-version }}",
+				RunsOn: "${{ matrix.os }}",
+				Needs:  gh.StringArray{"a"},
+				Steps: []*gh.Step{
+					{Uses: "actions/checkout@v2"},
+					{
+						Name: "Use Node.js ${{ matrix.node-version }}",
+						Uses: "actions/setup-node@v1",
+						With: map[string]interface{}{
+							"node-version": "${{ matrix.node-version }}",
+						},
+					},
+					{
+						Name: "install",
+						Run:  "yarn install\n",
+						With: map[string]interface{}{
+							"a": "b",
+						},
+					},
+
+					{Name: "test", Run: "yarn test"},
+				},
+				Strategy: gh.Strategy{
+					Matrix: gh.Matrix{
+						"os":           []gh.MatrixValue{"ubuntu-latest"},
+						"node-version": []gh.MatrixValue{"11.x", "12.x", "14.x"},
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, yamlTree(t, &output), yamlTree(t, &workflow))
+	// the events keep the order of the remote file
+	assert.Less(t, bytes.Index(commit.Blobs[0], []byte("push:")), bytes.Index(commit.Blobs[0], []byte("pull_request:")))
+
 	for _, entry := range h.Entries {
 		if entry.Level >= log.ErrorLevel {
 			t.Errorf("stderr should be empty, error: %v", entry)
@@ -565,7 +545,7 @@ func TestSync_WorkflowEmptyWorkflowFolderRemote(t *testing.T) {
 	})
 
 	args := &createRefRequest{
-		Ref: github.String("refs/heads/ghconfig/workflows/fixed_id"),
+		Ref: github.String("refs/heads/ghconfig/workflows/sync/fixed_id"),
 		SHA: github.String("aa218f56b14c9653891f9e74264a383fa43fefbd"),
 	}
 	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
@@ -578,8 +558,8 @@ func TestSync_WorkflowEmptyWorkflowFolderRemote(t *testing.T) {
 		}
 		fmt.Fprint(w, `
 		  {
-		    "ref": "refs/heads/ghconfig/workflows/fixed_id",
-		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/fixed_id",
+		    "ref": "refs/heads/ghconfig/workflows/sync/fixed_id",
+		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/sync/fixed_id",
 		    "object": {
 		      "type": "commit",
 		      "sha": "aa218f56b14c9653891f9e74264a383fa43fefbd",
@@ -589,12 +569,18 @@ func TestSync_WorkflowEmptyWorkflowFolderRemote(t *testing.T) {
 	})
 	input := &github.NewPullRequest{
 		Title: github.String("Synchronize (.github) configurations by ghconfig"),
-		Head:  github.String("ghconfig/workflows/fixed_id"),
+		Head:  github.String("ghconfig/workflows/sync/fixed_id"),
 		Base:  github.String("master"),
+		Body:  github.String(pullRequestBody("- `.github/workflows/ci.yaml` (created, +39 -0)")),
 		Draft: github.Bool(true),
 	}
 
 	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			// no open PR of ghconfig
+			fmt.Fprint(w, `[]`)
+			return
+		}
 		v := new(github.NewPullRequest)
 		json.NewDecoder(r.Body).Decode(v)
 
@@ -605,19 +591,7 @@ func TestSync_WorkflowEmptyWorkflowFolderRemote(t *testing.T) {
 
 		fmt.Fprint(w, `{"number":1, "html_url": "https://github.com/o/r/pull/20"}`)
 	})
-	mux.HandleFunc("/repos/o/r/contents/.github/workflows/ci.yaml", func(w http.ResponseWriter, r *http.Request) {
-		testMethod(t, r, "PUT")
-		fmt.Fprint(w, `{
-			"content":{
-				"name":"p"
-			},
-			"commit":{
-				"message":"m",
-				"sha":"f5f369044773ff9c6383c087466d12adb6fa0828",
-				"html_url": "https://github.com/o/r/blob/master/.github/workflows/nodejs.yml"
-			}
-		}`)
-	})
+	commit := handleCommit(t, mux, "ghconfig/workflows/sync/fixed_id")
 
 	ctx := context.Background()
 	sid := testIDGenerator{}
@@ -644,6 +618,9 @@ func TestSync_WorkflowEmptyWorkflowFolderRemote(t *testing.T) {
 		t.Fatalf("could not execute command, %v", err)
 	}
 
+	assert.Len(t, commit.Blobs, 1)
+	assert.Equal(t, ".github/workflows/ci.yaml", commit.Tree[0].GetPath())
+
 	for _, entry := range h.Entries {
 		if entry.Level >= log.ErrorLevel {
 			t.Errorf("stderr should be empty, error: %v", entry)
@@ -652,7 +629,7 @@ func TestSync_WorkflowEmptyWorkflowFolderRemote(t *testing.T) {
 }
 
 func TestSync_JSONPatch(t *testing.T) {
-	client, mux, serverURL, teardown := setup()
+	client, mux, _, teardown := setup()
 	defer teardown()
 
 	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
@@ -684,46 +661,19 @@ func TestSync_JSONPatch(t *testing.T) {
 		    }
 		  ]`)
 	})
-	mux.HandleFunc("/repos/o/r/contents/.github/workflows/ci.yaml", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			fmt.Fprint(w, `{
-				"type": "file",
-				"name": "f",
-				"path": ".github/workflows/ci.yaml",
-				"download_url": "`+serverURL+baseURLPath+`/download/.github/workflows/ci.yaml"
-			  }`)
-		case "PUT":
-			rr := readRepositoryContentFileOptions(r.Body)
-			workflow := gh.GithubWorkflow{}
-			yaml.Unmarshal(rr.Content, &workflow)
-
-			assert.Equal(t, workflow.Name, "CI")
-			fmt.Fprint(w, `
-			{
-				"content":{
-					"name":"CI"
-				},
-				"commit":{
-					"message":"m",
-					"sha":"f5f369044773ff9c6383c087466d12adb6fa0828",
-					"html_url": "https://github.com/o/r/blob/master/.github/workflows/ci.yml"
-				}
-			}`)
-		default:
-			t.Errorf("Request method: %v, want %v", r.Method, "PUT or GET")
-		}
-	})
-	mux.HandleFunc("/download/.github/workflows/ci.yaml", func(w http.ResponseWriter, r *http.Request) {
-		testMethod(t, r, "GET")
-		bytes, _ := yaml.Marshal(&gh.GithubWorkflow{
-			Name: "foo",
-		})
-		fmt.Fprint(w, string(bytes))
+	remoteFile, _ := yaml.Marshal(&gh.GithubWorkflow{
+		Name: "foo",
+		On: gh.On{
+			Push: &gh.Push{Branches: []string{"master"}},
+		},
+		Jobs: map[string]*gh.Job{
+			"build": {RunsOn: "ubuntu-latest"},
+		},
 	})
+	handleRepositoryFile(t, mux, "o/r", ".github/workflows/ci.yaml", remoteFile)
 
 	args := &createRefRequest{
-		Ref: github.String("refs/heads/ghconfig/workflows/fixed_id"),
+		Ref: github.String("refs/heads/ghconfig/workflows/patch/fixed_id"),
 		SHA: github.String("aa218f56b14c9653891f9e74264a383fa43fefbd"),
 	}
 	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
@@ -736,8 +686,8 @@ func TestSync_JSONPatch(t *testing.T) {
 		}
 		fmt.Fprint(w, `
 		  {
-		    "ref": "refs/heads/ghconfig/workflows/fixed_id",
-		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/fixed_id",
+		    "ref": "refs/heads/ghconfig/workflows/patch/fixed_id",
+		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/patch/fixed_id",
 		    "object": {
 		      "type": "commit",
 		      "sha": "aa218f56b14c9653891f9e74264a383fa43fefbd",
@@ -748,12 +698,18 @@ func TestSync_JSONPatch(t *testing.T) {
 
 	input := &github.NewPullRequest{
 		Title: github.String("Synchronize (.github) configurations by ghconfig"),
-		Head:  github.String("ghconfig/workflows/fixed_id"),
+		Head:  github.String("ghconfig/workflows/patch/fixed_id"),
 		Base:  github.String("master"),
+		Body:  github.String(pullRequestBody("- `.github/workflows/ci.yaml` (patched, +1 -1)")),
 		Draft: github.Bool(true),
 	}
 
 	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			// no open PR of ghconfig
+			fmt.Fprint(w, `[]`)
+			return
+		}
 		v := new(github.NewPullRequest)
 		json.NewDecoder(r.Body).Decode(v)
 
@@ -765,6 +721,8 @@ func TestSync_JSONPatch(t *testing.T) {
 		fmt.Fprint(w, `{"number":1, "html_url": "https://github.com/o/r/pull/20"}`)
 	})
 
+	commit := handleCommit(t, mux, "ghconfig/workflows/patch/fixed_id")
+
 	ctx := context.Background()
 	sid := testIDGenerator{}
 
@@ -791,6 +749,12 @@ func TestSync_JSONPatch(t *testing.T) {
 		t.Fatalf("could not execute command, %v", err)
 	}
 
+	assert.Len(t, commit.Blobs, 1)
+	workflow := gh.GithubWorkflow{}
+	yaml.Unmarshal(commit.Blobs[0], &workflow)
+
+	assert.Equal(t, workflow.Name, "CI")
+
 	for _, entry := range h.Entries {
 		if entry.Level >= log.ErrorLevel {
 			t.Errorf("stderr should be empty, error: %v", entry)
@@ -798,6 +762,55 @@ func TestSync_JSONPatch(t *testing.T) {
 	}
 }
 
+func TestSync_PatchCanNotBeApplied(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	// the patch replaces the name of a document which is a list
+	handleRepositoryFile(t, mux, "o/r", ".github/workflows/ci.yaml", []byte("- push\n"))
+
+	commit := handleCommit(t, mux, "master")
+
+	dir, err := ioutil.TempDir("", "report")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		PatchOnly:       true,
+		RootDir:         "../test/fixture/simple-patch",
+		ReportFormat:    "json",
+		ReportFile:      path.Join(dir, "report.json"),
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err = NewSyncCmd(cfg)
+	assert.EqualError(t, err, "1 of 1 repositories failed")
+	assert.Len(t, commit.Blobs, 0)
+
+	data, err := ioutil.ReadFile(cfg.ReportFile)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"status": "failed"`)
+	assert.Contains(t, string(data), "ci.yaml: ")
+}
+
 func TestSync_DependabotNotExistOnRemote(t *testing.T) {
 	client, mux, _, teardown := setup()
 	defer teardown()
@@ -837,7 +850,7 @@ func TestSync_DependabotNotExistOnRemote(t *testing.T) {
 	})
 
 	args := &createRefRequest{
-		Ref: github.String("refs/heads/ghconfig/workflows/fixed_id"),
+		Ref: github.String("refs/heads/ghconfig/workflows/sync/fixed_id"),
 		SHA: github.String("aa218f56b14c9653891f9e74264a383fa43fefbd"),
 	}
 	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
@@ -850,8 +863,8 @@ func TestSync_DependabotNotExistOnRemote(t *testing.T) {
 		}
 		fmt.Fprint(w, `
 		  {
-		    "ref": "refs/heads/ghconfig/workflows/fixed_id",
-		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/fixed_id",
+		    "ref": "refs/heads/ghconfig/workflows/sync/fixed_id",
+		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/sync/fixed_id",
 		    "object": {
 		      "type": "commit",
 		      "sha": "aa218f56b14c9653891f9e74264a383fa43fefbd",
@@ -861,12 +874,18 @@ func TestSync_DependabotNotExistOnRemote(t *testing.T) {
 	})
 	input := &github.NewPullRequest{
 		Title: github.String("Synchronize (.github) configurations by ghconfig"),
-		Head:  github.String("ghconfig/workflows/fixed_id"),
+		Head:  github.String("ghconfig/workflows/sync/fixed_id"),
 		Base:  github.String("master"),
+		Body:  github.String(pullRequestBody("- `.github/dependabot.yml` (created, +11 -0)")),
 		Draft: github.Bool(true),
 	}
 
 	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			// no open PR of ghconfig
+			fmt.Fprint(w, `[]`)
+			return
+		}
 		v := new(github.NewPullRequest)
 		json.NewDecoder(r.Body).Decode(v)
 
@@ -881,28 +900,13 @@ func TestSync_DependabotNotExistOnRemote(t *testing.T) {
 		switch r.Method {
 		case "GET":
 			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-		case "PUT":
-			rr := readRepositoryContentFileOptions(r.Body)
-			d := dependabot.GithubDependabot{}
-			yaml.Unmarshal(rr.Content, &d)
-
-			assert.Equal(t, d.Version, "2")
-			fmt.Fprint(w, `
-			{
-				"content":{
-					"name":"CI"
-				},
-				"commit":{
-					"message":"m",
-					"sha":"f5f369044773ff9c6383c087466d12adb6fa0828",
-					"html_url": "https://github.com/o/r/blob/master/.github/dependabot.yml"
-				}
-			}`)
 		default:
-			t.Errorf("Request method: %v, want %v", r.Method, "PUT or GET")
+			t.Errorf("Request method: %v, want %v", r.Method, "GET")
 		}
 	})
 
+	commit := handleCommit(t, mux, "ghconfig/workflows/sync/fixed_id")
+
 	ctx := context.Background()
 	sid := testIDGenerator{}
 
@@ -928,6 +932,12 @@ func TestSync_DependabotNotExistOnRemote(t *testing.T) {
 		t.Fatalf("could not execute command, %v", err)
 	}
 
+	assert.Len(t, commit.Blobs, 1)
+	d := dependabot.GithubDependabot{}
+	yaml.Unmarshal(commit.Blobs[0], &d)
+
+	assert.Equal(t, d.Version, "2")
+
 	for _, entry := range h.Entries {
 		if entry.Level >= log.ErrorLevel {
 			t.Errorf("stderr should be empty, error: %v", entry)
@@ -936,7 +946,7 @@ func TestSync_DependabotNotExistOnRemote(t *testing.T) {
 }
 
 func TestSync_DependabotExistOnRemote(t *testing.T) {
-	client, mux, serverURL, teardown := setup()
+	client, mux, _, teardown := setup()
 	defer teardown()
 
 	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
@@ -974,7 +984,7 @@ func TestSync_DependabotExistOnRemote(t *testing.T) {
 	})
 
 	args := &createRefRequest{
-		Ref: github.String("refs/heads/ghconfig/workflows/fixed_id"),
+		Ref: github.String("refs/heads/ghconfig/workflows/sync/fixed_id"),
 		SHA: github.String("aa218f56b14c9653891f9e74264a383fa43fefbd"),
 	}
 	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
@@ -987,8 +997,8 @@ func TestSync_DependabotExistOnRemote(t *testing.T) {
 		}
 		fmt.Fprint(w, `
 		  {
-		    "ref": "refs/heads/ghconfig/workflows/fixed_id",
-		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/fixed_id",
+		    "ref": "refs/heads/ghconfig/workflows/sync/fixed_id",
+		    "url": "https://api.github.com/repos/o/r/git/refs/heads/ghconfig/workflows/sync/fixed_id",
 		    "object": {
 		      "type": "commit",
 		      "sha": "aa218f56b14c9653891f9e74264a383fa43fefbd",
@@ -998,12 +1008,18 @@ func TestSync_DependabotExistOnRemote(t *testing.T) {
 	})
 	input := &github.NewPullRequest{
 		Title: github.String("Synchronize (.github) configurations by ghconfig"),
-		Head:  github.String("ghconfig/workflows/fixed_id"),
+		Head:  github.String("ghconfig/workflows/sync/fixed_id"),
 		Base:  github.String("master"),
+		Body:  github.String(pullRequestBody("- `.github/dependabot.yml` (merged, +9 -1)")),
 		Draft: github.Bool(true),
 	}
 
 	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			// no open PR of ghconfig
+			fmt.Fprint(w, `[]`)
+			return
+		}
 		v := new(github.NewPullRequest)
 		json.NewDecoder(r.Body).Decode(v)
 
@@ -1014,67 +1030,108 @@ func TestSync_DependabotExistOnRemote(t *testing.T) {
 
 		fmt.Fprint(w, `{"number":1, "html_url": "https://github.com/o/r/pull/20"}`)
 	})
-	mux.HandleFunc("/repos/o/r/contents/.github/dependabot.yml", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			fmt.Fprint(w, `{
-				"type": "file",
-				"name": "f",
-				"path": ".github/dependabot.yml",
-				"download_url": "`+serverURL+baseURLPath+`/download/.github/dependabot.yml"
-			  }`)
-		case "PUT":
-			rr := readRepositoryContentFileOptions(r.Body)
-			d := dependabot.GithubDependabot{}
-			yaml.Unmarshal(rr.Content, &d)
-
-			output := dependabot.GithubDependabot{
-				Version: "2",
-				Updates: []*dependabot.Updates{
-					{
-						Directory:             "/",
-						PackageEcosystem:      "docker",
-						OpenPullRequestsLimit: 0,
-						Schedule: dependabot.Schedule{
-							Interval: "weekly",
-						},
-					},
-					{
-						Directory:        "/",
-						PackageEcosystem: "npm",
-						Schedule: dependabot.Schedule{
-							Interval: "daily",
-						},
-					},
+	remoteFile, _ := yaml.Marshal(&dependabot.GithubDependabot{
+		Version: "1",
+		Updates: []*dependabot.Updates{
+			{
+				Directory:        "/foo",
+				PackageEcosystem: "gomod",
+				Schedule: dependabot.Schedule{
+					Interval: "weekly",
 				},
-			}
-			assert.EqualValues(t, d, output)
-			fmt.Fprint(w, `
+			},
+		},
+	})
+	handleRepositoryFile(t, mux, "o/r", ".github/dependabot.yml", remoteFile)
+
+	commit := handleCommit(t, mux, "ghconfig/workflows/sync/fixed_id")
+
+	ctx := context.Background()
+	sid := testIDGenerator{}
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         ctx,
+		DryRun:          false,
+		BaseBranch:      "master",
+		Sid:             sid,
+		CreatePR:        true,
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-dependabot",
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	assert.Len(t, commit.Blobs, 1)
+	d := dependabot.GithubDependabot{}
+	yaml.Unmarshal(commit.Blobs[0], &d)
+
+	output := dependabot.GithubDependabot{
+		Version: "2",
+		Updates: []*dependabot.Updates{
 			{
-				"content":{
-					"name":"CI"
+				Directory:        "/foo",
+				PackageEcosystem: "gomod",
+				Schedule: dependabot.Schedule{
+					Interval: "weekly",
 				},
-				"commit":{
-					"message":"m",
-					"sha":"f5f369044773ff9c6383c087466d12adb6fa0828",
-					"html_url": "https://github.com/o/r/blob/master/.github/dependabot.yml"
-				}
-			}`)
-		default:
-			t.Errorf("Request method: %v, want %v", r.Method, "PUT or GET")
+			},
+			{
+				Directory:             "/",
+				PackageEcosystem:      "docker",
+				OpenPullRequestsLimit: 0,
+				Schedule: dependabot.Schedule{
+					Interval: "weekly",
+				},
+			},
+			{
+				Directory:        "/",
+				PackageEcosystem: "npm",
+				Schedule: dependabot.Schedule{
+					Interval: "daily",
+				},
+			},
+		},
+	}
+	assert.EqualValues(t, d, output)
+
+	for _, entry := range h.Entries {
+		if entry.Level >= log.ErrorLevel {
+			t.Errorf("stderr should be empty, error: %v", entry)
 		}
+	}
+}
+
+func TestSync_WorkflowSchemaValidationFails(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
 	})
-	mux.HandleFunc("/download/.github/dependabot.yml", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
 		testMethod(t, r, "GET")
-		bytes, _ := yaml.Marshal(&dependabot.GithubDependabot{
-			Version: "1",
-			Updates: []*dependabot.Updates{
-				{
-					Directory: "/foo",
-				},
-			},
-		})
-		fmt.Fprint(w, string(bytes))
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no branch should be created for an invalid workflow")
+	})
+	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no pull request should be created for an invalid workflow")
 	})
 
 	ctx := context.Background()
@@ -1088,7 +1145,7 @@ func TestSync_DependabotExistOnRemote(t *testing.T) {
 		Sid:             sid,
 		CreatePR:        true,
 		RepositoryQuery: "o in:name",
-		RootDir:         "../test/fixture/simple-dependabot",
+		RootDir:         "../test/fixture/invalid-workflow",
 	}
 
 	h := memory.New()
@@ -1102,9 +1159,1407 @@ func TestSync_DependabotExistOnRemote(t *testing.T) {
 		t.Fatalf("could not execute command, %v", err)
 	}
 
+	warnings := 0
 	for _, entry := range h.Entries {
 		if entry.Level >= log.ErrorLevel {
 			t.Errorf("stderr should be empty, error: %v", entry)
 		}
+		if entry.Level == log.WarnLevel {
+			warnings++
+		}
+	}
+	assert.Equal(t, 1, warnings)
+}
+
+func TestSync_DependabotUnchangedOnRemote(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+	handleRepositoryFile(t, mux, "o/r", ".github/dependabot.yml", []byte(`version: "2"
+updates:
+  - package-ecosystem: docker
+    directory: /
+    schedule:
+      interval: weekly
+  - package-ecosystem: npm
+    directory: /
+    schedule:
+      interval: daily
+`))
+	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no branch should be created for unchanged files")
+	})
+	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+
+	ctx := context.Background()
+	sid := testIDGenerator{}
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         ctx,
+		DryRun:          false,
+		BaseBranch:      "master",
+		Sid:             sid,
+		CreatePR:        true,
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-dependabot",
 	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	for _, entry := range h.Entries {
+		if entry.Level >= log.ErrorLevel {
+			t.Errorf("stderr should be empty, error: %v", entry)
+		}
+	}
+}
+
+func TestSync_WorkflowUnchangedOnRemote(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"type": "file", "name": "ci.yaml", "path": ".github/workflows/ci.yaml", "sha": "sha"}]`)
+	})
+	// the file is rewritten by the encoder e.g `CI: true` and `needs: a` but has the same values
+	handleRepositoryFile(t, mux, "o/r", ".github/workflows/ci.yaml", []byte(`name: Node CI
+on:
+  push:
+    paths-ignore: ["**.md", "docs/**"]
+  pull_request:
+    paths-ignore: ["**.md", "docs/**"]
+env:
+  CI: true
+  A: o/r
+jobs:
+  build:
+    name: Node ${{ matrix.node-version }}
+    runs-on: ${{ matrix.os }}
+    needs: a
+    strategy:
+      matrix:
+        node-version: [11.x, 12.x, 14.x]
+        os: [ubuntu-latest]
+    steps:
+      - uses: actions/checkout@v2
+      - name: Use Node.js ${{ matrix.node-version }}
+        uses: actions/setup-node@v1
+        with:
+          node-version: ${{ matrix.node-version }}
+      - name: install
+        run: |
+          yarn install
+      - name: test
+        run: yarn test
+`))
+	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no branch should be created for unchanged files")
+	})
+	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+
+	ctx := context.Background()
+	sid := testIDGenerator{}
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         ctx,
+		DryRun:          false,
+		BaseBranch:      "master",
+		Sid:             sid,
+		CreatePR:        true,
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-workflow",
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	for _, entry := range h.Entries {
+		if entry.Level >= log.ErrorLevel {
+			t.Errorf("stderr should be empty, error: %v", entry)
+		}
+	}
+}
+
+func TestSync_WorkflowOnBaseBranch(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no pull request should be created")
+	})
+
+	commit := handleCommit(t, mux, "master")
+
+	ctx := context.Background()
+	sid := testIDGenerator{}
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         ctx,
+		DryRun:          false,
+		BaseBranch:      "master",
+		Sid:             sid,
+		CreatePR:        false,
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-workflow",
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	assert.Len(t, commit.Blobs, 1)
+	assert.Equal(t, "Update ci.yaml by ghconfig", commit.Message)
+
+	for _, entry := range h.Entries {
+		if entry.Level >= log.ErrorLevel {
+			t.Errorf("stderr should be empty, error: %v", entry)
+		}
+	}
+}
+
+func TestSync_RemoteWorkflowCanNotBeMerged(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"type": "file", "name": "ci.yaml", "path": ".github/workflows/ci.yaml", "sha": "sha"}]`)
+	})
+	handleRepositoryFile(t, mux, "o/r", ".github/workflows/ci.yaml", []byte("jobs: [build"))
+
+	commit := handleCommit(t, mux, "master")
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-workflow",
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	assert.EqualError(t, err, "1 of 1 repositories failed")
+	assert.Len(t, commit.Blobs, 0, "the remote file must not be replaced by the template")
+}
+
+func TestSync_FileChangedAfterRead(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+
+	commit := handleCommit(t, mux, "master")
+	// the workflow doesn't exist when it's read but was pushed before the commit
+	addRemoteFile(mux, "o/r", ".github/workflows/ci.yaml")
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-workflow",
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	assert.EqualError(t, err, "1 of 1 repositories failed")
+	assert.Len(t, commit.Blobs, 0)
+
+	logged := false
+	for _, entry := range h.Entries {
+		if entry.Level == log.ErrorLevel && strings.Contains(fmt.Sprint(entry.Fields.Get("error")), ".github/workflows/ci.yaml was created on master after it was read") {
+			logged = true
+		}
+	}
+	assert.True(t, logged, "the changed file should be reported")
+}
+
+func TestSync_SelectRepositoriesWithoutPrompt(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 7, "incomplete_results": false, "items": [
+			{"id":1, "name": "svc-a", "full_name": "o/svc-a"},
+			{"id":2, "name": "svc-b", "full_name": "o/svc-b"},
+			{"id":3, "name": "web", "full_name": "o/web"},
+			{"id":4, "name": "svc-archived", "full_name": "o/svc-archived", "archived": true},
+			{"id":5, "name": "svc-fork", "full_name": "o/svc-fork", "fork": true},
+			{"id":6, "name": "svc-disabled", "full_name": "o/svc-disabled", "disabled": true},
+			{"id":7, "name": "svc-template", "full_name": "o/svc-template", "is_template": true}
+		]}`)
+	})
+
+	reposFile, err := ioutil.TempFile("", "repos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(reposFile.Name())
+	fmt.Fprint(reposFile, "# services\no/svc-b\no/web\n\no/svc-fork\no/unknown\n")
+	reposFile.Close()
+
+	orig := Multiselect
+	Multiselect = func(_ []string, _ *[]string) error {
+		t.Error("prompt must not be opened")
+		return nil
+	}
+	defer func() {
+		Multiselect = orig
+	}()
+
+	type selectionTestCase struct {
+		Description string
+		Options     config.Config
+		Output      []string
+	}
+
+	testcases := []selectionTestCase{
+		{
+			Description: "All active repositories",
+			Options:     config.Config{SelectAll: true},
+			Output:      []string{"o/svc-a", "o/svc-b", "o/web"},
+		},
+		{
+			Description: "Include glob and exclude regex",
+			Options:     config.Config{IncludePatterns: []string{"o/svc-*"}, ExcludePatterns: []string{"/-b$/"}},
+			Output:      []string{"o/svc-a"},
+		},
+		{
+			Description: "Repositories file",
+			Options:     config.Config{ReposFile: reposFile.Name(), ExcludePatterns: []string{"o/web"}},
+			Output:      []string{"o/svc-b"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		cfg := testcase.Options
+		cfg.GithubClient = client
+		cfg.Context = context.Background()
+
+		_, targetRepos, err := selectRepositories(&cfg)
+		assert.Nil(t, err, testcase.Description)
+		assert.Equal(t, testcase.Output, targetRepos, testcase.Description)
+	}
+}
+
+func TestSync_SelectInstallationRepositories(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("installation must not look up the user")
+	})
+	mux.HandleFunc("/installation/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 2, "repositories": [
+			{"id":1, "name": "a", "full_name": "o/a"},
+			{"id":2, "name": "b", "full_name": "o/b", "archived": true}
+		]}`)
+	})
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		AppInstallation: true,
+		SelectAll:       true,
+	}
+
+	_, targetRepos, err := selectRepositories(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"o/a"}, targetRepos)
+}
+
+func TestSync_Report(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 2, "incomplete_results": false, "items": [
+			{"id":1, "name": "r", "full_name": "o/r", "html_url": "https://github.com/o/r", "owner": {"id":1, "Login": "o"}},
+			{"id":2, "name": "broken", "full_name": "o/broken", "owner": {"id":1, "Login": "o"}}
+		]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/o/broken/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	handleRepositoryCommit(t, mux, "o/r", "master")
+
+	reportFile, err := ioutil.TempFile("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reportFile.Close()
+	defer os.Remove(reportFile.Name())
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-workflow",
+		ReportFormat:    "json",
+		ReportFile:      reportFile.Name(),
+	}
+
+	log.SetHandler(memory.New())
+
+	stub := StubRepositorySelection([]string{"o/r", "o/broken"})
+	defer stub()
+
+	err = NewSyncCmd(cfg)
+	assert.EqualError(t, err, "1 of 2 repositories failed")
+
+	data, err := ioutil.ReadFile(reportFile.Name())
+	assert.Nil(t, err)
+
+	result := struct {
+		Repositories []struct {
+			Name      string
+			Status    string
+			CommitURL string `json:"commit_url"`
+			Errors    []string
+			Files     []struct {
+				Path   string
+				Action string
+			}
+		}
+	}{}
+	assert.Nil(t, json.Unmarshal(data, &result))
+	assert.Len(t, result.Repositories, 2)
+
+	assert.Equal(t, "o/broken", result.Repositories[0].Name)
+	assert.Equal(t, "failed", result.Repositories[0].Status)
+	assert.Len(t, result.Repositories[0].Errors, 1)
+
+	assert.Equal(t, "o/r", result.Repositories[1].Name)
+	assert.Equal(t, "updated", result.Repositories[1].Status)
+	assert.Equal(t, "https://github.com/o/r/commit/f5f369044773ff9c6383c087466d12adb6fa0828", result.Repositories[1].CommitURL)
+	assert.Equal(t, ".github/workflows/ci.yaml", result.Repositories[1].Files[0].Path)
+	assert.Equal(t, "created", result.Repositories[1].Files[0].Action)
+}
+
+func TestSync_FailurePolicy(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	repos := []string{}
+	items := []string{}
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("r%d", i)
+		repos = append(repos, "o/"+name)
+		items = append(items, fmt.Sprintf(`{"id":%d, "name": "%v", "full_name": "o/%v", "owner": {"id":1, "Login": "o"}}`, i, name, name))
+	}
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprintf(w, `{"total_count": 10, "incomplete_results": false, "items": [%v]}`, strings.Join(items, ","))
+	})
+	mux.HandleFunc("/repos/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	type failurePolicyTestCase struct {
+		Description string
+		FailFast    bool
+		MaxFailures int
+		Error       string
+	}
+
+	testcases := []failurePolicyTestCase{
+		{
+			Description: "Continue on error",
+			Error:       "10 of 10 repositories failed",
+		},
+		{
+			Description: "Fail fast",
+			FailFast:    true,
+			Error:       "run aborted after",
+		},
+		{
+			Description: "Max failures",
+			MaxFailures: 2,
+			Error:       "run aborted after",
+		},
+	}
+
+	log.SetHandler(memory.New())
+
+	stub := StubRepositorySelection(repos)
+	defer stub()
+
+	for _, testcase := range testcases {
+		cfg := &config.Config{
+			GithubClient:    client,
+			Context:         context.Background(),
+			BaseBranch:      "master",
+			Sid:             testIDGenerator{},
+			RepositoryQuery: "o in:name",
+			RootDir:         "../test/fixture/simple-workflow",
+			FailFast:        testcase.FailFast,
+			MaxFailures:     testcase.MaxFailures,
+		}
+
+		err := NewSyncCmd(cfg)
+		assert.NotNil(t, err, testcase.Description)
+		assert.Contains(t, err.Error(), testcase.Error, testcase.Description)
+	}
+}
+
+func TestSync_Interrupted(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	repos := []string{}
+	items := []string{}
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("r%d", i)
+		repos = append(repos, "o/"+name)
+		items = append(items, fmt.Sprintf(`{"id":%d, "name": "%v", "full_name": "o/%v", "owner": {"id":1, "Login": "o"}}`, i, name, name))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprintf(w, `{"total_count": 5, "incomplete_results": false, "items": [%v]}`, strings.Join(items, ","))
+	})
+	mux.HandleFunc("/repos/", func(w http.ResponseWriter, r *http.Request) {
+		// the signal arrives while the first repository is processed
+		cancel()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	reportFile, err := ioutil.TempFile("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reportFile.Close()
+	defer os.Remove(reportFile.Name())
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         ctx,
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-workflow",
+		Concurrency:     1,
+		ReportFile:      reportFile.Name(),
+	}
+
+	log.SetHandler(memory.New())
+
+	stub := StubRepositorySelection(repos)
+	defer stub()
+
+	err = NewSyncCmd(cfg)
+	assert.EqualError(t, err, "run was interrupted")
+
+	data, err := ioutil.ReadFile(reportFile.Name())
+	assert.Nil(t, err)
+
+	result := struct {
+		Repositories []struct {
+			Name   string
+			Status string
+		}
+	}{}
+	assert.Nil(t, json.Unmarshal(data, &result))
+	assert.Len(t, result.Repositories, 5)
+	assert.Equal(t, "skipped", result.Repositories[0].Status)
+	for _, repository := range result.Repositories[1:] {
+		assert.Equal(t, "not-started", repository.Status, repository.Name)
+	}
+}
+
+func TestSync_Resume(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 2, "incomplete_results": false, "items": [
+			{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}},
+			{"id":2, "name": "broken", "full_name": "o/broken", "owner": {"id":1, "Login": "o"}}
+		]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+	brokenRequests := 0
+	mux.HandleFunc("/repos/o/broken/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		brokenRequests++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	commit := handleRepositoryCommit(t, mux, "o/r", "master")
+
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-workflow",
+		JournalFile:     path.Join(dir, config.JournalFileName),
+	}
+
+	log.SetHandler(memory.New())
+
+	stub := StubRepositorySelection([]string{"o/r", "o/broken"})
+	defer stub()
+
+	err = NewSyncCmd(cfg)
+	assert.EqualError(t, err, "1 of 2 repositories failed")
+	assert.Len(t, commit.Blobs, 1)
+	assert.Equal(t, 1, brokenRequests)
+
+	// the completed repository is skipped, the failed one is processed again
+	cfg.Resume = true
+	err = NewSyncCmd(cfg)
+	assert.EqualError(t, err, "1 of 2 repositories failed")
+	assert.Len(t, commit.Blobs, 1)
+	assert.Equal(t, 2, brokenRequests)
+
+	// without --resume all repositories are processed
+	cfg.Resume = false
+	err = NewSyncCmd(cfg)
+	assert.EqualError(t, err, "1 of 2 repositories failed")
+	assert.Len(t, commit.Blobs, 2)
+}
+
+func handleOpenPullRequests(t *testing.T, mux *http.ServeMux) {
+	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if r.FormValue("state") != "open" || r.FormValue("base") != "master" {
+			t.Errorf("unexpected filter %v", r.URL.RawQuery)
+		}
+		// the PRs of patch runs and manifest targets are listed before the PR of sync runs
+		fmt.Fprint(w, `[
+			{"number": 3, "html_url": "https://github.com/o/r/pull/3", "head": {"ref": "feature", "repo": {"full_name": "o/r"}}},
+			{"number": 9, "html_url": "https://github.com/o/r/pull/9", "head": {"ref": "ghconfig/workflows/patch/old", "repo": {"full_name": "o/r"}}},
+			{"number": 11, "html_url": "https://github.com/o/r/pull/11", "head": {"ref": "ghconfig/workflows/sync-node/old", "repo": {"full_name": "o/r"}}},
+			{"number": 5, "html_url": "https://github.com/o/r/pull/5", "head": {"ref": "ghconfig/workflows/sync/fork", "repo": {"full_name": "someone/r"}}},
+			{"number": 7, "node_id": "PR_7", "html_url": "https://github.com/o/r/pull/7", "head": {"ref": "ghconfig/workflows/sync/old", "repo": {"full_name": "o/r"}}}
+		]`)
+	})
+}
+
+// handleUntouchedPullRequests fails the test when one of the PRs is changed.
+func handleUntouchedPullRequests(t *testing.T, mux *http.ServeMux, numbers ...int) {
+	for _, number := range numbers {
+		number := number
+		mux.HandleFunc(fmt.Sprintf("/repos/o/r/pulls/%d", number), func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("PR #%d of another command or target must not be changed", number)
+		})
+	}
+}
+
+func TestSync_UpdateExistingPullRequest(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/o/r/git/matching-refs/heads/master", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"ref": "refs/heads/master", "object": {"type": "commit", "sha": "aa218f56b14c9653891f9e74264a383fa43fefbd"}}]`)
+	})
+	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no branch should be created when a PR is open")
+	})
+	handleOpenPullRequests(t, mux)
+	handleUntouchedPullRequests(t, mux, 9, 11)
+
+	var edit *github.PullRequest
+	mux.HandleFunc("/repos/o/r/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+		edit = new(github.PullRequest)
+		json.NewDecoder(r.Body).Decode(edit)
+		fmt.Fprint(w, `{"number": 7, "html_url": "https://github.com/o/r/pull/7"}`)
+	})
+	var mutation struct {
+		Query     string            `json:"query"`
+		Variables map[string]string `json:"variables"`
+	}
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&mutation)
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	commit := handleRepositoryCommit(t, mux, "o/r", "ghconfig/workflows/sync/old")
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		CreatePR:        true,
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-workflow",
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	for _, entry := range h.Entries {
+		assert.NotEqual(t, log.ErrorLevel, entry.Level, entry.Message)
+	}
+
+	// the branch is reset to a new commit on top of the base branch
+	assert.Len(t, commit.Blobs, 1)
+	assert.True(t, commit.Force)
+
+	assert.NotNil(t, edit)
+	assert.Equal(t, pullRequestBody("- `.github/workflows/ci.yaml` (created, +39 -0)"), edit.GetBody())
+	assert.Equal(t, "Synchronize (.github) configurations by ghconfig", edit.GetTitle())
+
+	// the reused PR is converted to a draft like a new one
+	assert.Contains(t, mutation.Query, "convertPullRequestToDraft")
+	assert.Equal(t, "PR_7", mutation.Variables["id"])
+}
+
+func TestSync_CloseObsoletePullRequest(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+	handleRepositoryFile(t, mux, "o/r", ".github/dependabot.yml", []byte(`version: "2"
+updates:
+  - package-ecosystem: docker
+    directory: /
+    schedule:
+      interval: weekly
+  - package-ecosystem: npm
+    directory: /
+    schedule:
+      interval: daily
+`))
+	handleOpenPullRequests(t, mux)
+	handleUntouchedPullRequests(t, mux, 9, 11)
+
+	var edit *github.PullRequest
+	mux.HandleFunc("/repos/o/r/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+		edit = new(github.PullRequest)
+		json.NewDecoder(r.Body).Decode(edit)
+		fmt.Fprint(w, `{"number": 7, "state": "closed"}`)
+	})
+	branchDeleted := false
+	mux.HandleFunc("/repos/o/r/git/refs/heads/ghconfig/workflows/sync/old", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		branchDeleted = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		CreatePR:        true,
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-dependabot",
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	for _, entry := range h.Entries {
+		assert.NotEqual(t, log.ErrorLevel, entry.Level, entry.Message)
+	}
+
+	assert.NotNil(t, edit)
+	assert.Equal(t, "closed", edit.GetState())
+	assert.True(t, branchDeleted)
+}
+
+func TestSync_PatchKeepsPullRequestOfSync(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	// the patched workflow doesn't exist, the patch run has no changes
+	handleOpenPullRequests(t, mux)
+	handleUntouchedPullRequests(t, mux, 7, 11)
+	mux.HandleFunc("/repos/o/r/git/refs/heads/ghconfig/workflows/sync/old", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("the branch of the sync PR must not be deleted")
+	})
+
+	var edit *github.PullRequest
+	mux.HandleFunc("/repos/o/r/pulls/9", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+		edit = new(github.PullRequest)
+		json.NewDecoder(r.Body).Decode(edit)
+		fmt.Fprint(w, `{"number": 9, "state": "closed"}`)
+	})
+	branchDeleted := false
+	mux.HandleFunc("/repos/o/r/git/refs/heads/ghconfig/workflows/patch/old", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		branchDeleted = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		CreatePR:        true,
+		PatchOnly:       true,
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-patch",
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	// only the PR of the patch run is closed
+	assert.NotNil(t, edit)
+	assert.Equal(t, "closed", edit.GetState())
+	assert.True(t, branchDeleted)
+}
+
+func TestSync_PullRequestMetadata(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/o/r/git/matching-refs/heads/master", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"ref": "refs/heads/master", "object": {"type": "commit", "sha": "aa218f56b14c9653891f9e74264a383fa43fefbd"}}]`)
+	})
+	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `{"ref": "refs/heads/ghconfig/workflows/sync/fixed_id", "object": {"type": "commit", "sha": "aa218f56b14c9653891f9e74264a383fa43fefbd"}}`)
+	})
+
+	input := &github.NewPullRequest{
+		Title: github.String(`Sync "r" & co`),
+		Head:  github.String("ghconfig/workflows/sync/fixed_id"),
+		Base:  github.String("master"),
+		Body:  github.String("<!-- managed by ghconfig -->\nChanged 1 files of O/R"),
+		Draft: github.Bool(false),
+	}
+	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		v := new(github.NewPullRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		assert.Equal(t, input, v)
+		fmt.Fprint(w, `{"number":1, "html_url": "https://github.com/o/r/pull/1"}`)
+	})
+
+	mux.HandleFunc("/repos/o/r/labels/ghconfig", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"name": "ghconfig"}`)
+	})
+	mux.HandleFunc("/repos/o/r/labels/ci", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.WriteHeader(http.StatusNotFound)
+	})
+	var createdLabel *github.Label
+	mux.HandleFunc("/repos/o/r/labels", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		createdLabel = new(github.Label)
+		json.NewDecoder(r.Body).Decode(createdLabel)
+		fmt.Fprint(w, `{"name": "ci"}`)
+	})
+	var labels []string
+	mux.HandleFunc("/repos/o/r/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&labels)
+		fmt.Fprint(w, `[]`)
+	})
+	var assignees struct {
+		Assignees []string `json:"assignees"`
+	}
+	mux.HandleFunc("/repos/o/r/issues/1/assignees", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&assignees)
+		fmt.Fprint(w, `{}`)
+	})
+	var issue github.IssueRequest
+	mux.HandleFunc("/repos/o/r/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+		json.NewDecoder(r.Body).Decode(&issue)
+		fmt.Fprint(w, `{}`)
+	})
+	var reviewers github.ReviewersRequest
+	mux.HandleFunc("/repos/o/r/pulls/1/requested_reviewers", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&reviewers)
+		fmt.Fprint(w, `{}`)
+	})
+
+	handleCommit(t, mux, "ghconfig/workflows/sync/fixed_id")
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		CreatePR:        true,
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-workflow",
+		PullRequest: config.PullRequestOptions{
+			Title:          `Sync "$(( .Repo.GetName ))" & co`,
+			Body:           "<!-- managed by ghconfig -->\nChanged $(( len .Files )) files of $(( .Repo.GetFullName | upper ))",
+			Labels:         []string{"ghconfig", "ci"},
+			Reviewers:      []string{"alice"},
+			TeamReviewers:  []string{"platform"},
+			Assignees:      []string{"bob"},
+			Milestone:      3,
+			ReadyForReview: true,
+		},
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	for _, entry := range h.Entries {
+		assert.NotEqual(t, log.ErrorLevel, entry.Level, entry.Message)
+	}
+
+	assert.Equal(t, "ci", createdLabel.GetName())
+	assert.Equal(t, []string{"ghconfig", "ci"}, labels)
+	assert.Equal(t, []string{"bob"}, assignees.Assignees)
+	assert.Equal(t, 3, issue.GetMilestone())
+	assert.Equal(t, []string{"alice"}, reviewers.Reviewers)
+	assert.Equal(t, []string{"platform"}, reviewers.TeamReviewers)
+}
+
+func handleThreeWayRepository(t *testing.T, mux *http.ServeMux, remote, base string) {
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no pull request should be created")
+	})
+	handleRepositoryFile(t, mux, "o/r", ".github/dependabot.yml", []byte(remote))
+
+	state, _ := yaml.Marshal(&config.State{Files: map[string]string{".github/dependabot.yml": base}})
+	handleRepositoryFile(t, mux, "o/r", config.StateFilePath, state)
+}
+
+func TestSync_ThreeWayMerge(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	// the template changed the npm interval, the repository changed the docker interval
+	base := `
+version: 2
+updates:
+  - package-ecosystem: docker
+    directory: /
+    schedule:
+      interval: weekly
+  - package-ecosystem: npm
+    directory: /
+    schedule:
+      interval: weekly
+  - package-ecosystem: pip
+    directory: /
+    schedule:
+      interval: weekly
+`
+	remote := `
+version: 2
+updates:
+  - package-ecosystem: docker
+    directory: /
+    schedule:
+      interval: monthly
+  - package-ecosystem: npm
+    directory: /
+    schedule:
+      interval: weekly
+  - package-ecosystem: pip
+    directory: /
+    schedule:
+      interval: weekly
+`
+	handleThreeWayRepository(t, mux, remote, base)
+
+	commit := handleCommit(t, mux, "master")
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-dependabot",
+		ThreeWayMerge:   true,
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	assert.Len(t, commit.Blobs, 2)
+
+	d := dependabot.GithubDependabot{}
+	yaml.Unmarshal(commit.Blobs[0], &d)
+	assert.Len(t, d.Updates, 2, "the removed pip entry is removed from the remote file")
+	assert.Equal(t, "docker", d.Updates[0].PackageEcosystem)
+	assert.Equal(t, "monthly", d.Updates[0].Schedule.Interval, "the change of the repository is kept")
+	assert.Equal(t, 0, d.Updates[0].OpenPullRequestsLimit)
+	assert.Equal(t, "npm", d.Updates[1].PackageEcosystem)
+	assert.Equal(t, "daily", d.Updates[1].Schedule.Interval, "the change of the template is applied")
+
+	state := config.State{}
+	yaml.Unmarshal(commit.Blobs[1], &state)
+	assert.Contains(t, state.Files, ".github/dependabot.yml")
+	assert.Contains(t, state.Files[".github/dependabot.yml"], "daily")
+
+	for _, entry := range h.Entries {
+		if entry.Level >= log.ErrorLevel {
+			t.Errorf("stderr should be empty, error: %v", entry)
+		}
+	}
+}
+
+func TestSync_ThreeWayMergeConflict(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	// the template and the repository changed the npm interval
+	base := `
+version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /
+    schedule:
+      interval: weekly
+`
+	remote := `
+version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /
+    schedule:
+      interval: monthly
+`
+	handleThreeWayRepository(t, mux, remote, base)
+
+	commit := handleCommit(t, mux, "master")
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-dependabot",
+		ThreeWayMerge:   true,
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	assert.Len(t, commit.Blobs, 0)
+
+	conflicts := []string{}
+	for _, entry := range h.Entries {
+		if entry.Level == log.WarnLevel && strings.HasPrefix(entry.Message, "merge conflict") {
+			conflicts = append(conflicts, entry.Message)
+		}
+	}
+	assert.Equal(t, []string{"merge conflict in .github/dependabot.yml at /updates/package-ecosystem=npm;directory=~1/schedule/interval"}, conflicts)
+}
+
+func TestSync_ThreeWayMergeDirectives(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	base := `
+version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /
+    schedule:
+      interval: weekly
+`
+	handleThreeWayRepository(t, mux, base, base)
+	mux.HandleFunc("/repos/o/r/git/blobs", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no file should be committed")
+	})
+
+	dir, err := ioutil.TempDir("", "three-way")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.MkdirAll(path.Join(dir, ".ghconfig"), 0755))
+	err = ioutil.WriteFile(path.Join(dir, ".ghconfig", "dependabot.yml"), []byte(`version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /
+    $delete: true
+`), 0644)
+	assert.Nil(t, err)
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         dir,
+		ThreeWayMerge:   true,
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	// the directives can't be applied on the three-way merge, the repository is not updated
+	err = NewSyncCmd(cfg)
+	assert.EqualError(t, err, "1 of 1 repositories failed")
+
+	messages := []string{}
+	for _, entry := range h.Entries {
+		if entry.Level >= log.ErrorLevel {
+			messages = append(messages, fmt.Sprint(entry.Fields["error"]))
+		}
+	}
+	assert.Contains(t, messages, "dependabot.yml: $merge and $delete can't be used with the three-way merge, remove the values from the template instead")
+}
+
+func TestSync_ThreeWayMergeDeletedFile(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":1, "Login": "o"}`)
+	})
+	mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"total_count": 1, "incomplete_results": false, "items": [{"id":1, "name": "r", "full_name": "o/r", "owner": {"id":1, "Login": "o"}}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/o/r/git/blobs", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("the deleted file should not be created again")
+	})
+	// the dependabot file was applied before and deleted in the repository afterwards
+	state, _ := yaml.Marshal(&config.State{Files: map[string]string{".github/dependabot.yml": "version: 2\n"}})
+	handleRepositoryFile(t, mux, "o/r", config.StateFilePath, state)
+
+	cfg := &config.Config{
+		GithubClient:    client,
+		Context:         context.Background(),
+		BaseBranch:      "master",
+		Sid:             testIDGenerator{},
+		RepositoryQuery: "o in:name",
+		RootDir:         "../test/fixture/simple-dependabot",
+		ThreeWayMerge:   true,
+	}
+
+	h := memory.New()
+	log.SetHandler(h)
+
+	stub := StubRepositorySelection([]string{"o/r"})
+	defer stub()
+
+	err := NewSyncCmd(cfg)
+	if err != nil {
+		t.Fatalf("could not execute command, %v", err)
+	}
+
+	warnings := []string{}
+	for _, entry := range h.Entries {
+		if entry.Level == log.WarnLevel {
+			warnings = append(warnings, entry.Message)
+		}
+	}
+	assert.Contains(t, warnings, "dependabot file .github/dependabot.yml was deleted in the repository and isn't created again")
+}
+
+func TestSync_MarshalRemoteFileKeepsKeyOrder(t *testing.T) {
+	remote := []byte(`# ci
+jobs:
+  build:
+    steps:
+      - run: make
+        name: build
+    runs-on: ubuntu-latest
+on: [pull_request, push]
+name: ci
+`)
+	workflow := gh.GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal(remote, &workflow))
+	workflow.Env = gh.Env{"CI": true}
+
+	// the remote file is re-encoded, the keys keep the order of the remote file
+	output, err := marshalRemoteFile(&config.Config{PreserveFormat: false}, remote, &workflow)
+	assert.Nil(t, err)
+	assert.Equal(t, `jobs:
+    build:
+        steps:
+            - run: make
+              name: build
+        runs-on: ubuntu-latest
+"on":
+    - pull_request
+    - push
+name: ci
+env:
+    CI: true
+`, string(output))
+}
+
+func TestSync_ProgressDescription(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	transport := ratelimit.NewTransport(nil, 2)
+	client := &http.Client{Transport: transport}
+	cfg := &config.Config{RateLimit: transport}
+
+	assert.Equal(t, "Processing repositories... (requests 0/2)", progressDescription(cfg))
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Processing repositories... (requests 1/2)", progressDescription(cfg))
+	_, err = client.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Processing repositories... (request budget of 2 exhausted, skipping)", progressDescription(cfg))
+
+	// without a budget only the progress is shown
+	assert.Equal(t, "Processing repositories...", progressDescription(&config.Config{RateLimit: ratelimit.NewTransport(nil, 0)}))
 }
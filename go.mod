@@ -14,6 +14,7 @@ require (
 	github.com/briandowns/spinner v1.11.1
 	github.com/cheynewallace/tabby v1.1.0
 	github.com/evanphx/json-patch v4.9.0+incompatible
+	github.com/fatih/color v1.7.0
 	github.com/google/go-github/v32 v32.1.0
 	github.com/google/uuid v1.1.2 // indirect
 	github.com/huandu/xstrings v1.3.2 // indirect
@@ -22,6 +23,7 @@ require (
 	github.com/mitchellh/copystructure v1.0.0 // indirect
 	github.com/pieterclaerhout/go-waitgroup v1.0.7
 	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/schollz/progressbar/v3 v3.5.1
 	github.com/stretchr/testify v1.6.1
 	github.com/teris-io/shortid v0.0.0-20171029131806-771a37caa5cf
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"ghconfig/internal/ratelimit"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// GitHub rejects JWTs which are valid for more than 10 minutes
+	jwtExpiration = 9 * time.Minute
+	// the issue time is backdated to allow clock drift
+	jwtClockDrift = time.Minute
+	// installation tokens are refreshed before they expire
+	tokenExpiryMargin = time.Minute
+	// token requests without a configured transport fail when GitHub doesn't respond
+	tokenRequestTimeout = time.Minute
+)
+
+type (
+	// jwtTransport authenticates all requests as the GitHub App.
+	jwtTransport struct {
+		appID int64
+		key   *rsa.PrivateKey
+		base  http.RoundTripper
+	}
+
+	installationTokenSource struct {
+		ctx            context.Context
+		client         *github.Client
+		installationID int64
+	}
+)
+
+// NewInstallationTokenSource returns a token source which creates installation access tokens of a
+// GitHub App. Tokens are cached and refreshed automatically shortly before they expire.
+// An empty apiURL refers to github.com. Token requests are sent through base and respect the rate
+// limits of the GitHub API, a nil base uses http.DefaultTransport with a timeout.
+func NewInstallationTokenSource(ctx context.Context, appID, installationID int64, privateKey []byte, apiURL string, base http.RoundTripper) (oauth2.TokenSource, error) {
+	key, err := ParsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if base == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.ResponseHeaderTimeout = tokenRequestTimeout
+		base = transport
+	}
+	// the JWT is signed for every attempt so that it doesn't expire while waiting for the rate limit
+	httpClient := &http.Client{
+		Transport: ratelimit.NewTransport(&jwtTransport{appID: appID, key: key, base: base}, 0),
+	}
+
+	client := github.NewClient(httpClient)
+	if apiURL != "" {
+		client, err = github.NewEnterpriseClient(apiURL, apiURL, httpClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return oauth2.ReuseTokenSource(nil, &installationTokenSource{
+		ctx:            ctx,
+		client:         client,
+		installationID: installationID,
+	}), nil
+}
+
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	token, _, err := s.client.Apps.CreateInstallationToken(s.ctx, s.installationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create installation token: %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: token.GetToken(),
+		Expiry:      token.GetExpiresAt().Add(-tokenExpiryMargin),
+	}, nil
+}
+
+func (t *jwtTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	jwt, err := NewAppJWT(t.appID, t.key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+jwt)
+	return t.base.RoundTrip(r)
+}
+
+// NewAppJWT creates a JSON Web Token (RS256) to authenticate as a GitHub App.
+func NewAppJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-jwtClockDrift).Unix(),
+		"exp": now.Add(jwtExpiration).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// ParsePrivateKey parses a PEM encoded RSA private key in PKCS#1 or PKCS#8 format.
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not a RSA key")
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return key, data
+}
+
+func TestNewAppJWT(t *testing.T) {
+	key, _ := newTestKey(t)
+	now := time.Unix(1600000000, 0)
+
+	jwt, err := NewAppJWT(42, key, now)
+	assert.Nil(t, err)
+
+	parts := strings.Split(jwt, ".")
+	assert.Len(t, parts, 3)
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	assert.Nil(t, err)
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	assert.Nil(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature))
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	assert.Nil(t, err)
+	claims := map[string]int64{}
+	assert.Nil(t, json.Unmarshal(payload, &claims))
+	assert.Equal(t, map[string]int64{"iss": 42, "iat": 1599999940, "exp": 1600000540}, claims)
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key, pkcs1 := newTestKey(t)
+
+	parsed, err := ParsePrivateKey(pkcs1)
+	assert.Nil(t, err)
+	assert.Equal(t, key.N, parsed.N)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Nil(t, err)
+	parsed, err = ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.Nil(t, err)
+	assert.Equal(t, key.N, parsed.N)
+
+	_, err = ParsePrivateKey([]byte("invalid"))
+	assert.NotNil(t, err)
+}
+
+func TestInstallationTokenSource(t *testing.T) {
+	_, data := newTestKey(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/v3/app/installations/7/access_tokens", r.URL.Path)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Bearer "))
+		fmt.Fprintf(w, `{"token": "t%d", "expires_at": "%s"}`, requests, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	ts, err := NewInstallationTokenSource(context.Background(), 42, 7, data, server.URL+"/api/v3/", nil)
+	assert.Nil(t, err)
+
+	token, err := ts.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, "t1", token.AccessToken)
+
+	// the token is reused until it expires
+	token, err = ts.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, "t1", token.AccessToken)
+	assert.Equal(t, 1, requests)
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestInstallationTokenSourceTransport(t *testing.T) {
+	_, data := newTestKey(t)
+
+	authorizations := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		if len(authorizations) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprintf(w, `{"token": "t", "expires_at": "%s"}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	base := &countingTransport{}
+	ts, err := NewInstallationTokenSource(context.Background(), 42, 7, data, server.URL+"/api/v3/", base)
+	assert.Nil(t, err)
+
+	// the rate limited request is retried through the configured transport
+	token, err := ts.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, "t", token.AccessToken)
+	assert.Equal(t, 2, base.requests)
+	for _, authorization := range authorizations {
+		assert.True(t, strings.HasPrefix(authorization, "Bearer "))
+	}
+}
@@ -1,18 +1,96 @@
 package common
 
-import "sort"
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DeleteMarker removes a field of the remote file when it is used as value in a local template
+// e.g `OLD_VAR: $delete`. List items are removed with `$delete:<item>`.
+const DeleteMarker = "$delete"
+
+// directives are the keys of local templates which control the merge and are never pushed.
+var directives = map[string]bool{DeleteMarker: true, "$merge": true, "$before": true, "$after": true}
+
+// HasDirectives reports whether the local template uses any merge directive as key or the delete marker
+// as value e.g `$delete: true`, `OLD_VAR: $delete` or `[$delete:linux]`. Comments and strings which only
+// contain a directive e.g `run: echo $delete` are no directives. Invalid YAML has no directives.
+func HasDirectives(data []byte) bool {
+	node := yaml.Node{}
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return false
+	}
+	return hasDirectives(&node)
+}
+
+func hasDirectives(node *yaml.Node) bool {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if hasDirectives(child) {
+				return true
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			if directives[node.Content[i].Value] || hasDirectives(node.Content[i+1]) {
+				return true
+			}
+		}
+	case yaml.ScalarNode:
+		return node.Value == DeleteMarker || strings.HasPrefix(node.Value, DeleteMarker+":")
+	}
+	return false
+}
+
+// IsDeleted reports whether the value of a local template is the delete marker.
+func IsDeleted(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && s == DeleteMarker
+}
+
+// MergeString returns src or dst when src is empty. The delete marker results in an empty string.
+func MergeString(src, dst string) string {
+	if src == DeleteMarker {
+		return ""
+	}
+	if src == "" {
+		return dst
+	}
+	return src
+}
 
 func MergeStringMap(src, dst map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = map[string]string{}
+	}
 	for k, v := range src {
+		if v == DeleteMarker {
+			delete(dst, k)
+			continue
+		}
 		dst[k] = v
 	}
 
 	return dst
 }
 
-func Unique(a, b []string) []string {
-	stringSlice := append(a, b...)
+// Unique returns the items of dst followed by the items of src which are missing in dst. Duplicates are
+// removed and the order of both lists is preserved. Items marked with `$delete:<item>` are removed.
+func Unique(src, dst []string) []string {
+	stringSlice := append(append([]string{}, dst...), src...)
 	keys := make(map[string]bool)
+	for _, entry := range stringSlice {
+		if strings.HasPrefix(entry, DeleteMarker+":") {
+			keys[entry] = true
+			keys[strings.TrimPrefix(entry, DeleteMarker+":")] = true
+		}
+	}
 	list := []string{}
 	for _, entry := range stringSlice {
 		if _, value := keys[entry]; !value {
@@ -25,7 +103,85 @@ func Unique(a, b []string) []string {
 		return nil
 	}
 
-	sort.Strings(list)
+	return list
+}
+
+// RemoveDeleted removes all list items which are marked with `$delete:<item>`.
+func RemoveDeleted(list []string) []string {
+	result := []string{}
+	for _, entry := range list {
+		if !strings.HasPrefix(entry, DeleteMarker+":") {
+			result = append(result, entry)
+		}
+	}
+	if len(result) == len(list) {
+		return list
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// UniqueValues is like Unique for lists of scalars of any type e.g ports `[8080, "9090:90"]`. Items are
+// compared by their string representation so that `$delete:8080` removes the port 8080.
+func UniqueValues(src, dst []interface{}) []interface{} {
+	items := append(append([]interface{}{}, dst...), src...)
+	keys := make(map[string]bool)
+	for _, item := range items {
+		if entry, ok := item.(string); ok && strings.HasPrefix(entry, DeleteMarker+":") {
+			keys[entry] = true
+			keys[strings.TrimPrefix(entry, DeleteMarker+":")] = true
+		}
+	}
+	list := []interface{}{}
+	for _, item := range items {
+		key := fmt.Sprint(item)
+		if !keys[key] {
+			keys[key] = true
+			list = append(list, item)
+		}
+	}
+
+	if len(list) == 0 {
+		return nil
+	}
 
 	return list
 }
+
+// RemoveDeletedValues is like RemoveDeleted for lists of scalars of any type.
+func RemoveDeletedValues(list []interface{}) []interface{} {
+	result := []interface{}{}
+	for _, item := range list {
+		if entry, ok := item.(string); !ok || !strings.HasPrefix(entry, DeleteMarker+":") {
+			result = append(result, item)
+		}
+	}
+	if len(result) == len(list) {
+		return list
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// MergeMap merges src into dst. Keys of src take precedence over the keys of dst.
+func MergeMap(src, dst map[string]interface{}) map[string]interface{} {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = map[string]interface{}{}
+	}
+	for k, v := range src {
+		if IsDeleted(v) {
+			delete(dst, k)
+			continue
+		}
+		dst[k] = v
+	}
+
+	return dst
+}
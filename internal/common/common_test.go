@@ -0,0 +1,50 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasDirectives(t *testing.T) {
+	testcases := []struct {
+		Description string
+		Input       string
+		Output      bool
+	}{
+		{
+			Description: "Delete marker as value",
+			Input:       "env:\n  OLD_VAR: $delete\n",
+			Output:      true,
+		},
+		{
+			Description: "Delete marker of a list item",
+			Input:       "runs-on: [self-hosted, '$delete:linux']\n",
+			Output:      true,
+		},
+		{
+			Description: "Directives as keys",
+			Input:       "steps:\n  - name: lint\n    $before: test\n",
+			Output:      true,
+		},
+		{
+			Description: "Merge strategies",
+			Input:       "$merge:\n  /jobs/build/steps: replace\n",
+			Output:      true,
+		},
+		{
+			Description: "Directives in comments and strings are ignored",
+			Input:       "# use $delete to remove a value\nsteps:\n  - run: echo $delete $merge\n    name: \"$before the tests\"\n",
+			Output:      false,
+		},
+		{
+			Description: "Invalid YAML",
+			Input:       "env: [$delete\n",
+			Output:      false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		assert.Equal(t, testcase.Output, HasDirectives([]byte(testcase.Input)), testcase.Description)
+	}
+}
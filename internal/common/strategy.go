@@ -0,0 +1,342 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MergeStrategy overrides the default merge behavior of a path in a local template.
+type MergeStrategy string
+
+const (
+	// StrategyReplace uses the local value and drops the remote value.
+	StrategyReplace MergeStrategy = "replace"
+	// StrategyMerge merges maps recursively and lists by the identity of their items.
+	StrategyMerge MergeStrategy = "merge"
+	// StrategyAppend keeps the remote value and appends the local items which are missing.
+	StrategyAppend MergeStrategy = "append"
+	// StrategyKeepRemote keeps the remote value. The local value is only used when the remote value is missing.
+	StrategyKeepRemote MergeStrategy = "keep-remote"
+)
+
+// MergeStrategies maps JSON pointers (RFC 6901) to strategies. A `*` segment matches any key or index
+// e.g `/jobs/*/runs-on`.
+type MergeStrategies map[string]MergeStrategy
+
+// Validate reports unknown strategies and malformed paths.
+func (s MergeStrategies) Validate() error {
+	for p, strategy := range s {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("invalid merge path %q: must start with /", p)
+		}
+		switch strategy {
+		case StrategyReplace, StrategyMerge, StrategyAppend, StrategyKeepRemote:
+		default:
+			return fmt.Errorf("unknown merge strategy %q for path %v", strategy, p)
+		}
+	}
+	return nil
+}
+
+// ToTree converts a value to its generic YAML representation of maps, lists and scalars.
+func ToTree(value interface{}) (interface{}, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	err = yaml.Unmarshal(data, &tree)
+	return tree, err
+}
+
+// ApplyStrategies applies the strategies on the merged value, which must be a pointer. remote and local
+// are the generic trees (see ToTree) of both files before the merge. Shorter paths are applied first so
+// that a more specific path takes precedence. merged is only replaced when a strategy changed a value.
+func ApplyStrategies(merged interface{}, remote, local interface{}, strategies MergeStrategies) error {
+	if len(strategies) == 0 {
+		return nil
+	}
+	if err := strategies.Validate(); err != nil {
+		return err
+	}
+	target := reflect.ValueOf(merged)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("merge strategies can't be applied on %T: not a pointer", merged)
+	}
+
+	tree, err := ToTree(merged)
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(strategies))
+	for p := range strategies {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := splitPointer(paths[i]), splitPointer(paths[j])
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return paths[i] < paths[j]
+	})
+
+	changed := false
+	for _, p := range paths {
+		pattern := splitPointer(p)
+		matches := map[string][]string{}
+		for _, concrete := range append(expandPath(remote, pattern, nil), expandPath(local, pattern, nil)...) {
+			matches[strings.Join(concrete, "/")] = concrete
+		}
+		for _, concrete := range matches {
+			remoteValue, remoteOk := lookupPath(remote, concrete)
+			localValue, localOk := lookupPath(local, concrete)
+
+			var value interface{}
+			switch strategies[p] {
+			case StrategyReplace:
+				if !localOk {
+					continue
+				}
+				value = localValue
+			case StrategyKeepRemote:
+				if !remoteOk {
+					continue
+				}
+				value = remoteValue
+			case StrategyAppend:
+				if !remoteOk || !localOk {
+					continue
+				}
+				value = appendTree(remoteValue, localValue)
+			case StrategyMerge:
+				if !remoteOk || !localOk {
+					continue
+				}
+				value = mergeTree(remoteValue, localValue)
+			}
+			if current, ok := lookupPath(tree, concrete); ok && reflect.DeepEqual(current, value) {
+				continue
+			}
+			tree = setPath(tree, concrete, value)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	// decode into a new value so that merged is left untouched on errors
+	result := reflect.New(target.Elem().Type())
+	err = yaml.Unmarshal(data, result.Interface())
+	if err != nil {
+		return err
+	}
+	target.Elem().Set(result.Elem())
+	return nil
+}
+
+func splitPointer(p string) []string {
+	segments := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	if len(segments) == 1 && segments[0] == "" {
+		return nil
+	}
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+	}
+	return segments
+}
+
+// expandPath returns all concrete paths of the tree which match the pattern.
+func expandPath(tree interface{}, pattern []string, prefix []string) [][]string {
+	if len(pattern) == 0 {
+		return [][]string{append([]string{}, prefix...)}
+	}
+	segment, rest := pattern[0], pattern[1:]
+	paths := [][]string{}
+	switch node := tree.(type) {
+	case map[string]interface{}:
+		for key, child := range node {
+			if segment == "*" || segment == key {
+				paths = append(paths, expandPath(child, rest, append(prefix, key))...)
+			}
+		}
+	case []interface{}:
+		for i, child := range node {
+			if segment == "*" || segment == strconv.Itoa(i) {
+				paths = append(paths, expandPath(child, rest, append(prefix, strconv.Itoa(i)))...)
+			}
+		}
+	}
+	return paths
+}
+
+func lookupPath(tree interface{}, p []string) (interface{}, bool) {
+	for _, segment := range p {
+		switch node := tree.(type) {
+		case map[string]interface{}:
+			child, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			tree = child
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			tree = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return tree, true
+}
+
+// setPath sets the value at the path. Missing maps on the way are created, paths into missing
+// list items are ignored.
+func setPath(tree interface{}, p []string, value interface{}) interface{} {
+	if len(p) == 0 {
+		return value
+	}
+	segment, rest := p[0], p[1:]
+	switch node := tree.(type) {
+	case map[string]interface{}:
+		node[segment] = setPath(node[segment], rest, value)
+		return node
+	case []interface{}:
+		i, err := strconv.Atoi(segment)
+		if err != nil || i < 0 || i >= len(node) {
+			return node
+		}
+		node[i] = setPath(node[i], rest, value)
+		return node
+	case nil:
+		return map[string]interface{}{segment: setPath(nil, rest, value)}
+	}
+	return tree
+}
+
+// appendTree keeps all remote items and adds the local items which are missing.
+func appendTree(remote, local interface{}) interface{} {
+	switch remoteNode := remote.(type) {
+	case []interface{}:
+		localNode, ok := local.([]interface{})
+		if !ok {
+			return remote
+		}
+		list := append([]interface{}{}, remoteNode...)
+		for _, item := range localNode {
+			if indexTreeItem(list, item, false) < 0 {
+				list = append(list, item)
+			}
+		}
+		return list
+	case map[string]interface{}:
+		localNode, ok := local.(map[string]interface{})
+		if !ok {
+			return remote
+		}
+		result := map[string]interface{}{}
+		for k, v := range localNode {
+			result[k] = v
+		}
+		for k, v := range remoteNode {
+			result[k] = v
+		}
+		return result
+	}
+	return remote
+}
+
+// mergeTree merges maps recursively. List items with the same identity (id, name, ...) are merged,
+// all other local items are appended. Local scalars take precedence.
+func mergeTree(remote, local interface{}) interface{} {
+	switch remoteNode := remote.(type) {
+	case []interface{}:
+		localNode, ok := local.([]interface{})
+		if !ok {
+			return local
+		}
+		list := append([]interface{}{}, remoteNode...)
+		for _, item := range localNode {
+			i := indexTreeItem(list, item, true)
+			if i < 0 {
+				list = append(list, item)
+				continue
+			}
+			list[i] = mergeTree(list[i], item)
+		}
+		return list
+	case map[string]interface{}:
+		localNode, ok := local.(map[string]interface{})
+		if !ok {
+			return local
+		}
+		result := map[string]interface{}{}
+		for k, v := range remoteNode {
+			result[k] = v
+		}
+		for k, v := range localNode {
+			if remoteValue, ok := result[k]; ok {
+				result[k] = mergeTree(remoteValue, v)
+				continue
+			}
+			result[k] = v
+		}
+		return result
+	}
+	return local
+}
+
+func indexTreeItem(list []interface{}, item interface{}, byIdentity bool) int {
+	for i, candidate := range list {
+		if reflect.DeepEqual(candidate, item) {
+			return i
+		}
+	}
+	if !byIdentity {
+		return -1
+	}
+	identity := treeIdentity(item)
+	if identity == "" {
+		return -1
+	}
+	for i, candidate := range list {
+		if treeIdentity(candidate) == identity {
+			return i
+		}
+	}
+	return -1
+}
+
+func treeIdentity(item interface{}) string {
+	fields, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	for _, keys := range identityKeys {
+		identity := ""
+		for _, key := range keys {
+			value, ok := fields[key]
+			if !ok {
+				identity = ""
+				break
+			}
+			identity += fmt.Sprintf("%s=%v;", key, value)
+		}
+		if identity != "" {
+			return identity
+		}
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyStrategies(t *testing.T) {
+	testcases := []struct {
+		Description string
+		Merged      map[string]interface{}
+		Remote      map[string]interface{}
+		Local       map[string]interface{}
+		Strategies  MergeStrategies
+		Output      map[string]interface{}
+	}{
+		{
+			Description: "Replace uses the local value",
+			Merged:      map[string]interface{}{"env": map[string]interface{}{"a": "1", "b": "2"}},
+			Remote:      map[string]interface{}{"env": map[string]interface{}{"a": "1"}},
+			Local:       map[string]interface{}{"env": map[string]interface{}{"b": "2"}},
+			Strategies:  MergeStrategies{"/env": StrategyReplace},
+			Output:      map[string]interface{}{"env": map[string]interface{}{"b": "2"}},
+		},
+		{
+			Description: "Keep-remote uses the remote value of all matching paths",
+			Merged:      map[string]interface{}{"jobs": map[string]interface{}{"a": map[string]interface{}{"runs-on": "local"}, "b": map[string]interface{}{"runs-on": "local"}}},
+			Remote:      map[string]interface{}{"jobs": map[string]interface{}{"a": map[string]interface{}{"runs-on": "remote"}}},
+			Local:       map[string]interface{}{"jobs": map[string]interface{}{"a": map[string]interface{}{"runs-on": "local"}, "b": map[string]interface{}{"runs-on": "local"}}},
+			Strategies:  MergeStrategies{"/jobs/*/runs-on": StrategyKeepRemote},
+			Output:      map[string]interface{}{"jobs": map[string]interface{}{"a": map[string]interface{}{"runs-on": "remote"}, "b": map[string]interface{}{"runs-on": "local"}}},
+		},
+		{
+			Description: "Append keeps the order of the remote list",
+			Merged:      map[string]interface{}{"labels": []interface{}{"a", "b", "c"}},
+			Remote:      map[string]interface{}{"labels": []interface{}{"c", "a"}},
+			Local:       map[string]interface{}{"labels": []interface{}{"b", "a"}},
+			Strategies:  MergeStrategies{"/labels": StrategyAppend},
+			Output:      map[string]interface{}{"labels": []interface{}{"c", "a", "b"}},
+		},
+		{
+			Description: "Merge merges list items with the same identity",
+			Merged: map[string]interface{}{"steps": []interface{}{
+				map[string]interface{}{"name": "test", "run": "make ci"},
+			}},
+			Remote: map[string]interface{}{"steps": []interface{}{
+				map[string]interface{}{"name": "lint", "run": "make lint"},
+				map[string]interface{}{"name": "test", "run": "make test", "shell": "bash"},
+			}},
+			Local: map[string]interface{}{"steps": []interface{}{
+				map[string]interface{}{"name": "test", "run": "make ci"},
+			}},
+			Strategies: MergeStrategies{"/steps": StrategyMerge},
+			Output: map[string]interface{}{"steps": []interface{}{
+				map[string]interface{}{"name": "lint", "run": "make lint"},
+				map[string]interface{}{"name": "test", "run": "make ci", "shell": "bash"},
+			}},
+		},
+		{
+			Description: "Escaped pointer segments",
+			Merged:      map[string]interface{}{"a/b": "local"},
+			Remote:      map[string]interface{}{"a/b": "remote"},
+			Local:       map[string]interface{}{"a/b": "local"},
+			Strategies:  MergeStrategies{"/a~1b": StrategyKeepRemote},
+			Output:      map[string]interface{}{"a/b": "remote"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		merged := testcase.Merged
+		err := ApplyStrategies(&merged, testcase.Remote, testcase.Local, testcase.Strategies)
+		assert.Nil(t, err, testcase.Description)
+		assert.EqualValues(t, testcase.Output, merged, testcase.Description)
+	}
+}
+
+func TestApplyStrategies_NoPointer(t *testing.T) {
+	merged := map[string]interface{}{"a": "local"}
+	err := ApplyStrategies(merged, map[string]interface{}{"a": "remote"}, merged, MergeStrategies{"/a": StrategyKeepRemote})
+	assert.EqualError(t, err, "merge strategies can't be applied on map[string]interface {}: not a pointer")
+}
+
+func TestMergeStrategies_Validate(t *testing.T) {
+	assert.Nil(t, MergeStrategies{"/jobs/*/steps": StrategyReplace}.Validate())
+	assert.NotNil(t, MergeStrategies{"jobs": StrategyReplace}.Validate())
+	assert.NotNil(t, MergeStrategies{"/jobs": "override"}.Validate())
+}
@@ -0,0 +1,207 @@
+package common
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ThreeWayMerge merges the generic trees (see ToTree) of the remote file and the local template with the
+// last applied template as common ancestor. Changes of the template are applied, changes of the remote
+// file are kept. The JSON pointers of values which were changed differently on both sides are returned
+// as conflicts, the remote value is kept for them.
+func ThreeWayMerge(base, remote, local interface{}) (interface{}, []string) {
+	m := &threeWay{}
+	merged, _ := m.merge("", base, remote, local, base != nil, remote != nil, local != nil)
+	sort.Strings(m.conflicts)
+	return merged, m.conflicts
+}
+
+type threeWay struct {
+	conflicts []string
+}
+
+func (m *threeWay) merge(p string, base, remote, local interface{}, inBase, inRemote, inLocal bool) (interface{}, bool) {
+	switch {
+	case samePresence(inLocal, local, inBase, base):
+		// the template didn't change
+		return remote, inRemote
+	case samePresence(inRemote, remote, inBase, base):
+		// the remote file didn't change
+		return local, inLocal
+	case samePresence(inRemote, remote, inLocal, local):
+		return remote, inRemote
+	}
+
+	if inRemote && inLocal {
+		remoteMap, remoteIsMap := remote.(map[string]interface{})
+		localMap, localIsMap := local.(map[string]interface{})
+		if remoteIsMap && localIsMap {
+			baseMap, _ := base.(map[string]interface{})
+			return m.mergeMaps(p, baseMap, remoteMap, localMap), true
+		}
+		remoteList, remoteIsList := remote.([]interface{})
+		localList, localIsList := local.([]interface{})
+		if remoteIsList && localIsList {
+			baseList, _ := base.([]interface{})
+			return m.mergeLists(p, baseList, remoteList, localList), true
+		}
+	}
+
+	m.conflicts = append(m.conflicts, pointer(p))
+	return remote, inRemote
+}
+
+func (m *threeWay) mergeMaps(p string, base, remote, local map[string]interface{}) map[string]interface{} {
+	keys := map[string]bool{}
+	for _, node := range []map[string]interface{}{base, remote, local} {
+		for key := range node {
+			keys[key] = true
+		}
+	}
+
+	result := map[string]interface{}{}
+	for key := range keys {
+		baseValue, inBase := base[key]
+		remoteValue, inRemote := remote[key]
+		localValue, inLocal := local[key]
+		value, ok := m.merge(p+"/"+escapePointer(key), baseValue, remoteValue, localValue, inBase, inRemote, inLocal)
+		if ok {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// mergeLists merges the items of lists which represent the same entity (see sameListItem) recursively.
+// Items without an identity e.g scalars are compared by value, they are only added or removed.
+// The order of the remote list is kept, new items of the template are inserted after their previous item.
+func (m *threeWay) mergeLists(p string, base, remote, local []interface{}) []interface{} {
+	result := []interface{}{}
+	for _, item := range remote {
+		baseItem, inBase := findListItem(base, item)
+		localItem, inLocal := findListItem(local, item)
+		if !hasIdentity(item) {
+			// the template removed the item
+			if inBase && !inLocal {
+				continue
+			}
+			result = append(result, item)
+			continue
+		}
+		value, ok := m.merge(p+"/"+listItemSegment(item), baseItem, item, localItem, inBase, true, inLocal)
+		if ok {
+			result = append(result, value)
+		}
+	}
+
+	previous := -1
+	for _, item := range local {
+		if i := indexListItem(result, item); i >= 0 {
+			previous = i
+			continue
+		}
+		if _, inRemote := findListItem(remote, item); inRemote {
+			// removed by the merge above
+			continue
+		}
+		baseItem, inBase := findListItem(base, item)
+		value := item
+		if hasIdentity(item) {
+			var ok bool
+			value, ok = m.merge(p+"/"+listItemSegment(item), baseItem, nil, item, inBase, false, true)
+			if !ok {
+				continue
+			}
+		} else if inBase {
+			// the item was removed on purpose on the remote
+			continue
+		}
+		previous++
+		result = append(result[:previous], append([]interface{}{value}, result[previous:]...)...)
+	}
+	return result
+}
+
+// sameListItem reports whether both items represent the same entity. Maps are matched like steps by their
+// id, name or action (`uses` without the version), then by the identity keys of other lists e.g dependabot
+// updates. All other items are compared by value.
+func sameListItem(a, b interface{}) bool {
+	aFields, aIsMap := a.(map[string]interface{})
+	bFields, bIsMap := b.(map[string]interface{})
+	if !aIsMap || !bIsMap {
+		return reflect.DeepEqual(a, b)
+	}
+	for _, key := range []string{"id", "name"} {
+		aValue, aOk := aFields[key]
+		bValue, bOk := bFields[key]
+		if aOk && bOk {
+			return reflect.DeepEqual(aValue, bValue)
+		}
+	}
+	if identity := treeIdentity(a); identity != "" {
+		return identity == treeIdentity(b)
+	}
+	aUses, aOk := aFields["uses"].(string)
+	bUses, bOk := bFields["uses"].(string)
+	if aOk && bOk {
+		return actionName(aUses) == actionName(bUses)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func hasIdentity(item interface{}) bool {
+	return listItemSegment(item) != ""
+}
+
+// listItemSegment is the path segment of a list item e.g `/jobs/build/steps/name=test`. It's empty for
+// items without an identity.
+func listItemSegment(item interface{}) string {
+	if identity := treeIdentity(item); identity != "" {
+		return escapePointer(strings.TrimSuffix(identity, ";"))
+	}
+	fields, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if uses, ok := fields["uses"].(string); ok {
+		return escapePointer("uses=" + actionName(uses))
+	}
+	return ""
+}
+
+// actionName returns the action of a step without the version e.g `actions/checkout`.
+func actionName(uses string) string {
+	return strings.SplitN(uses, "@", 2)[0]
+}
+
+func indexListItem(list []interface{}, item interface{}) int {
+	for i, candidate := range list {
+		if sameListItem(candidate, item) {
+			return i
+		}
+	}
+	return -1
+}
+
+func findListItem(list []interface{}, item interface{}) (interface{}, bool) {
+	if i := indexListItem(list, item); i >= 0 {
+		return list[i], true
+	}
+	return nil, false
+}
+
+func samePresence(aIn bool, a interface{}, bIn bool, b interface{}) bool {
+	return aIn == bIn && (!aIn || reflect.DeepEqual(a, b))
+}
+
+func escapePointer(segment string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(segment, "~", "~0"), "/", "~1")
+}
+
+func pointer(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return p
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	testcases := []struct {
+		Description string
+		Base        string
+		Remote      string
+		Local       string
+		Output      string
+		Conflicts   []string
+	}{
+		{
+			Description: "Changes of the template are applied",
+			Base:        "jobs: {build: {runs-on: ubuntu-18.04, timeout-minutes: 10}}",
+			Remote:      "jobs: {build: {runs-on: ubuntu-18.04, timeout-minutes: 10}}",
+			Local:       "jobs: {build: {runs-on: ubuntu-latest, timeout-minutes: 10}}",
+			Output:      "jobs: {build: {runs-on: ubuntu-latest, timeout-minutes: 10}}",
+		},
+		{
+			Description: "Changes of the remote file are kept",
+			Base:        "jobs: {build: {runs-on: ubuntu-latest, timeout-minutes: 10}}",
+			Remote:      "jobs: {build: {runs-on: self-hosted, timeout-minutes: 10}}",
+			Local:       "jobs: {build: {runs-on: ubuntu-latest, timeout-minutes: 20}}",
+			Output:      "jobs: {build: {runs-on: self-hosted, timeout-minutes: 20}}",
+		},
+		{
+			Description: "Removals of the template are applied and removals of the remote file are kept",
+			Base:        "env: {A: a, B: b}\njobs: {build: {runs-on: ubuntu-latest}, lint: {runs-on: ubuntu-latest}}",
+			Remote:      "env: {A: a, C: c}\njobs: {build: {runs-on: ubuntu-latest}, lint: {runs-on: ubuntu-latest}}",
+			Local:       "env: {B: b}\njobs: {build: {runs-on: ubuntu-latest}}",
+			Output:      "env: {C: c}\njobs: {build: {runs-on: ubuntu-latest}}",
+		},
+		{
+			Description: "Lists of scalars are merged as sets in the order of the remote file",
+			Base:        "branches: [main, develop]",
+			Remote:      "branches: [release, main, develop]",
+			Local:       "branches: [main, next]",
+			Output:      "branches: [release, main, next]",
+		},
+		{
+			Description: "Steps are merged by their identity",
+			Base:        "steps: [{name: checkout, uses: actions/checkout@v2}, {name: test, run: make test}]",
+			Remote:      "steps: [{name: checkout, uses: actions/checkout@v2}, {name: custom, run: make custom}, {name: test, run: make test}]",
+			Local:       "steps: [{name: checkout, uses: actions/checkout@v3}, {name: lint, run: make lint}, {name: test, run: make ci}]",
+			Output:      "steps: [{name: checkout, uses: actions/checkout@v3}, {name: lint, run: make lint}, {name: custom, run: make custom}, {name: test, run: make ci}]",
+		},
+		{
+			Description: "Unnamed steps are merged by their action, steps added to the repository are kept",
+			Base:        "steps: [{uses: actions/checkout@v2}, {uses: actions/setup-node@v3, with: {node-version: 16}}, {run: npm test}]",
+			Remote:      "steps: [{uses: actions/checkout@v2}, {run: npm run lint}, {uses: actions/setup-node@v3, with: {node-version: 16}}, {run: npm test}]",
+			Local:       "steps: [{uses: actions/checkout@v3}, {uses: actions/setup-node@v3, with: {node-version: 18}}, {run: npm test}]",
+			Output:      "steps: [{uses: actions/checkout@v3}, {run: npm run lint}, {uses: actions/setup-node@v3, with: {node-version: 18}}, {run: npm test}]",
+		},
+		{
+			Description: "Items without identity are added and removed by their value",
+			Base:        "steps: [{run: make build}, {run: make test}]",
+			Remote:      "steps: [{run: make build}, {run: make custom}, {run: make test}]",
+			Local:       "steps: [{run: make build}, {run: make lint}]",
+			Output:      "steps: [{run: make build}, {run: make lint}, {run: make custom}]",
+		},
+		{
+			Description: "Different changes of the same value are reported as conflicts and the remote value is kept",
+			Base:        "jobs: {build: {runs-on: ubuntu-18.04, steps: [{name: test, run: make test}]}}",
+			Remote:      "jobs: {build: {runs-on: self-hosted, steps: [{name: test, run: make check}]}}",
+			Local:       "jobs: {build: {runs-on: ubuntu-latest, steps: [{name: test, run: make ci}]}}",
+			Output:      "jobs: {build: {runs-on: self-hosted, steps: [{name: test, run: make check}]}}",
+			Conflicts:   []string{"/jobs/build/runs-on", "/jobs/build/steps/name=test/run"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		var base, remote, local, output interface{}
+		assert.Nil(t, yaml.Unmarshal([]byte(testcase.Base), &base), testcase.Description)
+		assert.Nil(t, yaml.Unmarshal([]byte(testcase.Remote), &remote), testcase.Description)
+		assert.Nil(t, yaml.Unmarshal([]byte(testcase.Local), &local), testcase.Description)
+		assert.Nil(t, yaml.Unmarshal([]byte(testcase.Output), &output), testcase.Description)
+
+		merged, conflicts := ThreeWayMerge(base, remote, local)
+		assert.EqualValues(t, output, merged, testcase.Description)
+		assert.EqualValues(t, testcase.Conflicts, conflicts, testcase.Description)
+	}
+}
@@ -0,0 +1,396 @@
+package common
+
+import (
+	"bytes"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultIndent = 2
+
+// MarshalWithFormat encodes value as YAML on top of the original document. Nodes whose value
+// didn't change are kept as they are, including comments, key order, anchors and scalar styles.
+func MarshalWithFormat(original []byte, value interface{}) ([]byte, error) {
+	doc := yaml.Node{}
+	err := yaml.Unmarshal(original, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := yaml.Node{}
+	err = desired.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return yaml.Marshal(value)
+	}
+
+	doc.Content[0] = ApplyNode(doc.Content[0], &desired)
+	clearMergeTags(&doc)
+
+	buf := bytes.Buffer{}
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(detectIndent(doc.Content[0]))
+	err = encoder.Encode(&doc)
+	if err != nil {
+		return nil, err
+	}
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MarshalWithKeyOrder encodes value as YAML with the key order of the original document. Keys which don't
+// exist in the original document follow in the order of value. Comments and styles are not preserved.
+func MarshalWithKeyOrder(original []byte, value interface{}) ([]byte, error) {
+	doc := yaml.Node{}
+	err := yaml.Unmarshal(original, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := yaml.Node{}
+	err = desired.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		orderKeys(&desired, doc.Content[0])
+	}
+	return yaml.Marshal(&desired)
+}
+
+// orderKeys sorts the keys of all mappings of node by the position of the same key in reference.
+func orderKeys(node, reference *yaml.Node) {
+	reference = resolveAlias(reference)
+
+	switch {
+	case node.Kind == yaml.MappingNode && reference.Kind == yaml.MappingNode:
+		position := map[string]int{}
+		mergeKey := -1
+		for i := 0; i < len(reference.Content)-1; i += 2 {
+			if !isMergeKey(reference.Content[i]) {
+				position[reference.Content[i].Value] = i
+			} else if mergeKey < 0 {
+				mergeKey = i
+			}
+		}
+		referencePairs := mappingPairs(reference)
+		// inherited keys of merge keys (<<) are placed at the position of the merge key
+		for key := range referencePairs {
+			if _, ok := position[key]; !ok && mergeKey >= 0 {
+				position[key] = mergeKey
+			}
+		}
+
+		pairs := [][]*yaml.Node{}
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			pairs = append(pairs, node.Content[i:i+2])
+		}
+		sort.SliceStable(pairs, func(i, j int) bool {
+			a, aOk := position[pairs[i][0].Value]
+			b, bOk := position[pairs[j][0].Value]
+			if aOk && bOk {
+				return a < b
+			}
+			return aOk && !bOk
+		})
+
+		content := []*yaml.Node{}
+		for _, pair := range pairs {
+			if referenceValue, ok := referencePairs[pair[0].Value]; ok {
+				orderKeys(pair[1], referenceValue)
+			}
+			content = append(content, pair...)
+		}
+		node.Content = content
+	case node.Kind == yaml.SequenceNode && reference.Kind == yaml.SequenceNode:
+		used := make([]bool, len(reference.Content))
+		for i, item := range node.Content {
+			j := findSequenceItem(reference.Content, used, item, i)
+			if j < 0 {
+				continue
+			}
+			used[j] = true
+			orderKeys(item, reference.Content[j])
+		}
+	case node.Kind == yaml.DocumentNode:
+		for _, child := range node.Content {
+			orderKeys(child, reference)
+		}
+	}
+}
+
+// ApplyNode updates dst so that it represents the same value as src. Unchanged nodes of dst
+// are preserved. The returned node must be used in place of dst.
+func ApplyNode(dst, src *yaml.Node) *yaml.Node {
+	if NodesEqual(dst, src) {
+		return dst
+	}
+
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		applyMapping(dst, src)
+		return dst
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		applySequence(dst, src)
+		return dst
+	case dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode:
+		if dst.Tag != src.Tag {
+			dst.Style = src.Style
+		}
+		dst.Value = src.Value
+		dst.Tag = src.Tag
+		return dst
+	}
+
+	// the kind of the node has changed or an alias was modified
+	src.HeadComment = dst.HeadComment
+	src.LineComment = dst.LineComment
+	src.FootComment = dst.FootComment
+	return src
+}
+
+func applyMapping(dst, src *yaml.Node) {
+	srcPairs := mappingPairs(src)
+	inherited := map[string]*yaml.Node{}
+	for i := 0; i < len(dst.Content)-1; i += 2 {
+		if isMergeKey(dst.Content[i]) {
+			for key, value := range mergedPairs(dst.Content[i+1]) {
+				inherited[key] = value
+			}
+		}
+	}
+
+	content := []*yaml.Node{}
+	seen := map[string]bool{}
+
+	for i := 0; i < len(dst.Content)-1; i += 2 {
+		key, value := dst.Content[i], dst.Content[i+1]
+		if isMergeKey(key) {
+			content = append(content, key, value)
+			continue
+		}
+		srcValue, ok := srcPairs[key.Value]
+		if !ok {
+			continue
+		}
+		seen[key.Value] = true
+		content = append(content, key, ApplyNode(value, srcValue))
+	}
+
+	for i := 0; i < len(src.Content)-1; i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		if seen[key.Value] {
+			continue
+		}
+		if inheritedValue, ok := inherited[key.Value]; ok && NodesEqual(inheritedValue, value) {
+			continue
+		}
+		content = append(content, key, value)
+	}
+
+	dst.Content = content
+}
+
+func applySequence(dst, src *yaml.Node) {
+	used := make([]bool, len(dst.Content))
+	content := []*yaml.Node{}
+
+	for i, item := range src.Content {
+		j := findSequenceItem(dst.Content, used, item, i)
+		if j < 0 {
+			content = append(content, item)
+			continue
+		}
+		used[j] = true
+		content = append(content, ApplyNode(dst.Content[j], item))
+	}
+
+	dst.Content = content
+}
+
+// findSequenceItem finds the counterpart of item in a sequence. Equal items are preferred
+// over items with the same identity (id, name, ...) and items at the same position.
+func findSequenceItem(items []*yaml.Node, used []bool, item *yaml.Node, index int) int {
+	for j, candidate := range items {
+		if !used[j] && NodesEqual(candidate, item) {
+			return j
+		}
+	}
+	if identity := nodeIdentity(item); identity != "" {
+		for j, candidate := range items {
+			if !used[j] && nodeIdentity(candidate) == identity {
+				return j
+			}
+		}
+	}
+	if index < len(items) && !used[index] && item.Kind == yaml.MappingNode && resolveAlias(items[index]).Kind == yaml.MappingNode {
+		// don't reuse an item which represents a different entity
+		if nodeIdentity(item) == "" || nodeIdentity(items[index]) == "" {
+			return index
+		}
+	}
+	return -1
+}
+
+var identityKeys = [][]string{
+	{"id"},
+	{"name"},
+	{"package-ecosystem", "directory"},
+	{"dependency-name"},
+	{"cron"},
+}
+
+func nodeIdentity(node *yaml.Node) string {
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	pairs := mappingPairs(node)
+	for _, keys := range identityKeys {
+		identity := ""
+		for _, key := range keys {
+			value, ok := pairs[key]
+			if !ok || value.Kind != yaml.ScalarNode {
+				identity = ""
+				break
+			}
+			identity += key + "=" + value.Value + ";"
+		}
+		if identity != "" {
+			return identity
+		}
+	}
+	return ""
+}
+
+// NodesEqual reports whether both nodes represent the same value. Scalars are compared
+// by their textual value so that e.g. `true` and `"true"` are equal.
+func NodesEqual(a, b *yaml.Node) bool {
+	a = resolveAlias(a)
+	b = resolveAlias(b)
+
+	if a.Kind != b.Kind {
+		// a single value is equal to a list with that value e.g `needs: a` and `needs: [a]`
+		if a.Kind == yaml.ScalarNode && b.Kind == yaml.SequenceNode && len(b.Content) == 1 {
+			return NodesEqual(a, b.Content[0])
+		}
+		if b.Kind == yaml.ScalarNode && a.Kind == yaml.SequenceNode && len(a.Content) == 1 {
+			return NodesEqual(a.Content[0], b)
+		}
+		return false
+	}
+
+	switch a.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+		for i := range a.Content {
+			if !NodesEqual(a.Content[i], b.Content[i]) {
+				return false
+			}
+		}
+		return true
+	case yaml.MappingNode:
+		aPairs := mappingPairs(a)
+		bPairs := mappingPairs(b)
+		if len(aPairs) != len(bPairs) {
+			return false
+		}
+		for key, value := range aPairs {
+			other, ok := bPairs[key]
+			if !ok || !NodesEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case yaml.ScalarNode:
+		// `key:`, `key: ~` and `key: null` are equal
+		if a.ShortTag() == "!!null" && b.ShortTag() == "!!null" {
+			return true
+		}
+		return a.Value == b.Value
+	}
+	return false
+}
+
+// mappingPairs returns all key value pairs of a mapping including the pairs of merge keys (<<).
+func mappingPairs(node *yaml.Node) map[string]*yaml.Node {
+	pairs := map[string]*yaml.Node{}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if isMergeKey(node.Content[i]) {
+			for key, value := range mergedPairs(node.Content[i+1]) {
+				if _, ok := pairs[key]; !ok {
+					pairs[key] = value
+				}
+			}
+			continue
+		}
+		pairs[node.Content[i].Value] = node.Content[i+1]
+	}
+	return pairs
+}
+
+func mergedPairs(node *yaml.Node) map[string]*yaml.Node {
+	node = resolveAlias(node)
+	pairs := map[string]*yaml.Node{}
+	switch node.Kind {
+	case yaml.MappingNode:
+		return mappingPairs(node)
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			for key, value := range mergedPairs(item) {
+				if _, ok := pairs[key]; !ok {
+					pairs[key] = value
+				}
+			}
+		}
+	}
+	return pairs
+}
+
+func isMergeKey(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Value == "<<" && (node.Tag == "" || node.Tag == "!!merge")
+}
+
+// clearMergeTags removes the explicit tag of merge keys, otherwise they are encoded as `!!merge <<`.
+func clearMergeTags(node *yaml.Node) {
+	if isMergeKey(node) {
+		node.Tag = ""
+	}
+	for _, child := range node.Content {
+		clearMergeTags(child)
+	}
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// detectIndent returns the indentation of the first nested block mapping.
+func detectIndent(node *yaml.Node) int {
+	if node.Kind != yaml.MappingNode || node.Style&yaml.FlowStyle != 0 {
+		return defaultIndent
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0 {
+			if indent := value.Content[0].Column - key.Column; indent > 0 {
+				return indent
+			}
+		}
+	}
+	return defaultIndent
+}
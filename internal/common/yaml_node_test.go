@@ -0,0 +1,187 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nodeTestCase struct {
+	Description string
+	Original    string
+	Value       interface{}
+	Output      string
+}
+
+func TestMarshalWithFormat(t *testing.T) {
+	testcases := []nodeTestCase{
+		{
+			Description: "Comments, key order and styles of unchanged nodes are preserved",
+			Original: `# workflow
+name: CI # the name
+on:
+  push:
+    branches: [master]
+env:
+  CI: true
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      # checkout first
+      - uses: actions/checkout@v2
+      - name: test
+        run: |
+          npm test
+`,
+			Value: map[string]interface{}{
+				"name": "CI",
+				"on": map[string]interface{}{
+					"push": map[string]interface{}{
+						"branches": []string{"master", "develop"},
+					},
+				},
+				"env": map[string]string{"CI": "true", "FOO": "bar"},
+				"jobs": map[string]interface{}{
+					"build": map[string]interface{}{
+						"runs-on": "ubuntu-latest",
+						"steps": []map[string]string{
+							{"uses": "actions/checkout@v2"},
+							{"name": "install", "run": "npm install"},
+							{"name": "test", "run": "npm run test\n"},
+						},
+					},
+				},
+			},
+			Output: `# workflow
+name: CI # the name
+on:
+  push:
+    branches: [master, develop]
+env:
+  CI: true
+  FOO: bar
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      # checkout first
+      - uses: actions/checkout@v2
+      - name: install
+        run: npm install
+      - name: test
+        run: |
+          npm run test
+`,
+		},
+		{
+			Description: "Removed keys are deleted and anchors are kept",
+			Original: `defaults: &defaults
+  runs-on: ubuntu-latest
+jobs:
+  build:
+    <<: *defaults
+    name: build
+    if: always()
+`,
+			Value: map[string]interface{}{
+				"defaults": map[string]string{"runs-on": "ubuntu-latest"},
+				"jobs": map[string]interface{}{
+					"build": map[string]string{
+						"runs-on": "ubuntu-latest",
+						"name":    "build",
+					},
+				},
+			},
+			Output: `defaults: &defaults
+  runs-on: ubuntu-latest
+jobs:
+  build:
+    <<: *defaults
+    name: build
+`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		output, err := MarshalWithFormat([]byte(testcase.Original), testcase.Value)
+		assert.Nil(t, err, testcase.Description)
+		assert.Equal(t, testcase.Output, string(output), testcase.Description)
+	}
+}
+
+func TestMarshalWithKeyOrder(t *testing.T) {
+	testcases := []nodeTestCase{
+		{
+			Description: "Keys keep the order of the original document, new keys are appended",
+			Original: `# workflow
+on: push
+jobs:
+  test:
+    steps:
+      - run: make test
+        name: test
+  build:
+    runs-on: ubuntu-latest
+`,
+			Value: map[string]interface{}{
+				"name": "ci",
+				"on":   "push",
+				"jobs": map[string]interface{}{
+					"build":  map[string]interface{}{"runs-on": "ubuntu-latest"},
+					"deploy": map[string]interface{}{"runs-on": "ubuntu-latest"},
+					"test": map[string]interface{}{"steps": []interface{}{
+						map[string]interface{}{"name": "lint", "run": "make lint"},
+						map[string]interface{}{"name": "test", "run": "make ci"},
+					}},
+				},
+			},
+			Output: `"on": push
+jobs:
+    test:
+        steps:
+            - name: lint
+              run: make lint
+            - run: make ci
+              name: test
+    build:
+        runs-on: ubuntu-latest
+    deploy:
+        runs-on: ubuntu-latest
+name: ci
+`,
+		},
+		{
+			Description: "Keys of merge keys are ordered like the keys of the anchor",
+			Original: `defaults: &defaults
+  runs-on: ubuntu-latest
+  timeout-minutes: 10
+build:
+  <<: *defaults
+  name: build
+`,
+			Value: map[string]interface{}{
+				"build": map[string]interface{}{"name": "build", "timeout-minutes": 10, "runs-on": "ubuntu-latest"},
+			},
+			Output: `build:
+    runs-on: ubuntu-latest
+    timeout-minutes: 10
+    name: build
+`,
+		},
+		{
+			Description: "Without an original document the order of value is used",
+			Original:    "",
+			Value:       map[string]interface{}{"on": "push", "name": "ci"},
+			Output: `name: ci
+"on": push
+`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		output, err := MarshalWithKeyOrder([]byte(testcase.Original), testcase.Value)
+		assert.Nil(t, err, testcase.Description)
+		assert.Equal(t, testcase.Output, string(output), testcase.Description)
+	}
+}
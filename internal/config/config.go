@@ -4,19 +4,49 @@ import (
 	"context"
 	"ghconfig/internal/dependabot"
 	gh "ghconfig/internal/github"
+	"ghconfig/internal/ratelimit"
 
 	"github.com/google/go-github/v32/github"
 )
 
 var (
-	BranchNamePattern   = "ghconfig/workflows/%s"
+	// BranchNamePattern is formatted with the branch group (command and manifest target) and a unique id
+	BranchNamePattern   = "ghconfig/workflows/%s/%s"
 	GhWorkflowDir       = "workflows"
 	GhConfigBaseDir     = ".ghconfig"
 	GhPatchesDir        = "patches"
 	GithubConfigBaseDir = ".github"
+	ManifestFileName    = "ghconfig.yaml"
+	JournalFileName     = "ghconfig-journal.json"
+	StateFilePath       = ".github/.ghconfig-state.yaml"
+	PullRequestTitle    = "Synchronize (.github) configurations by ghconfig"
+	PullRequestBody     = `This Pull-Request is managed by [ghconfig](https://github.com/StarpTech/ghconfig) and updated on every run.
+
+Changed files:
+
+$(( range .Files ))- ` + "`$(( .Path ))`" + ` ($(( .Action )), +$(( .Additions )) -$(( .Deletions )))
+$(( end ))`
+)
+
+const (
+	StatusUpdated   RepositoryStatus = "updated"
+	StatusUnchanged RepositoryStatus = "unchanged"
+	StatusSkipped   RepositoryStatus = "skipped"
+	StatusFailed    RepositoryStatus = "failed"
+	// StatusNotStarted is used for repositories which weren't processed because the run was cancelled
+	StatusNotStarted RepositoryStatus = "not-started"
+
+	FileCreated FileAction = "created"
+	FileMerged  FileAction = "merged"
+	FilePatched FileAction = "patched"
 )
 
 type (
+	// RepositoryStatus is the outcome of a repository
+	RepositoryStatus string
+	// FileAction describes how the content of a file was created
+	FileAction string
+
 	IDGenerator interface {
 		MustGenerate() string
 	}
@@ -31,6 +61,44 @@ type (
 		RootDir         string
 		CommitMessage   string
 		PatchOnly       bool
+		PreserveFormat  bool
+		// SelectAll, IncludePatterns, ExcludePatterns and ReposFile replace the interactive repository selection
+		SelectAll       bool
+		IncludePatterns []string
+		ExcludePatterns []string
+		ReposFile       string
+		// AppInstallation is set when the client is authenticated as a GitHub App installation
+		AppInstallation bool
+		// RateLimit is the transport of the GithubClient. It's optional.
+		RateLimit *ratelimit.Transport
+		// ReportFormat (json, junit or markdown) and ReportFile configure the report of the run
+		ReportFormat string
+		ReportFile   string
+		// FailFast cancels the run on the first failed repository, MaxFailures after the given number (0 = unlimited)
+		FailFast    bool
+		MaxFailures int
+		// Concurrency is the number of repositories which are processed in parallel
+		Concurrency int
+		// JournalFile records the outcome of every repository, an empty path disables the journal
+		JournalFile string
+		// Resume skips repositories which were completed with the same templates according to the journal
+		Resume      bool
+		PullRequest PullRequestOptions
+		// ThreeWayMerge merges remote files with the last applied template of the state file as common ancestor
+		ThreeWayMerge bool
+	}
+
+	// PullRequestOptions configures the PRs created by ghconfig. Title and Body are templates
+	// which have access to the TemplateVars and the changed Files.
+	PullRequestOptions struct {
+		Title          string
+		Body           string
+		Labels         []string
+		Reviewers      []string
+		TeamReviewers  []string
+		Assignees      []string
+		Milestone      int
+		ReadyForReview bool
 	}
 
 	TemplateVars = map[string]interface{}
@@ -41,6 +109,44 @@ type (
 		RepositoryPath string
 	}
 
+	TemplateSet struct {
+		Workflows  []*WorkflowTemplate
+		Patches    []*PatchData
+		Dependabot *DependabotTemplate
+	}
+
+	// Manifest maps repositories to the template sets which should be applied to them.
+	Manifest struct {
+		Github  ManifestGithub    `yaml:"github,omitempty" json:"github,omitempty"`
+		Targets []*ManifestTarget `yaml:"targets,omitempty" json:"targets,omitempty"`
+	}
+
+	// ManifestGithub configures the GitHub host. Command line flags take precedence.
+	ManifestGithub struct {
+		APIURL    string `yaml:"api-url,omitempty" json:"api-url,omitempty"`
+		UploadURL string `yaml:"upload-url,omitempty" json:"upload-url,omitempty"`
+	}
+
+	ManifestTarget struct {
+		Name     string             `yaml:"name,omitempty" json:"name,omitempty"`
+		Selector RepositorySelector `yaml:"selector,omitempty" json:"selector,omitempty"`
+		// Templates are directories with the same layout as the .ghconfig directory
+		Templates []string `yaml:"templates,omitempty" json:"templates,omitempty"`
+		// Patches are additional directories with JSON patches
+		Patches      []string     `yaml:"patches,omitempty" json:"patches,omitempty"`
+		Dependabot   string       `yaml:"dependabot,omitempty" json:"dependabot,omitempty"`
+		TemplateVars TemplateVars `yaml:"vars,omitempty" json:"vars,omitempty"`
+	}
+
+	// RepositorySelector matches repositories. All configured criteria must match.
+	RepositorySelector struct {
+		Query        string   `yaml:"query,omitempty" json:"query,omitempty"`
+		Topics       []string `yaml:"topics,omitempty" json:"topics,omitempty"`
+		Languages    []string `yaml:"languages,omitempty" json:"languages,omitempty"`
+		Repositories []string `yaml:"repositories,omitempty" json:"repositories,omitempty"`
+		Pattern      string   `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	}
+
 	WorkflowTemplate struct {
 		Workflow       *gh.GithubWorkflow
 		Filename       string
@@ -48,12 +154,22 @@ type (
 	}
 
 	RepositoryFileUpdateOptions struct {
-		FileContent *[]byte
-		Filename    string
-		SHA         string
-		Path        string
-		DisplayName string
-		URL         string
+		FileContent       *[]byte
+		RemoteFileContent *[]byte
+		Filename          string
+		SHA               string
+		Path              string
+		DisplayName       string
+		URL               string
+		Action            FileAction
+		// Template is the rendered local template which is recorded in the state file
+		Template []byte
+	}
+
+	// RemoteFile is a file of the repository together with its decoded content.
+	RemoteFile struct {
+		Content *github.RepositoryContent
+		Data    []byte
 	}
 
 	PatchData struct {
@@ -81,6 +197,32 @@ type (
 		RepositoryOptions *RepositoryUpdateOptions
 		TemplateVars      TemplateVars
 		PullRequestURL    string
+		CommitSHA         string
+		SchemaErrors      []*SchemaError
+		Conflicts         []*MergeConflict
+		Status            RepositoryStatus
+		Errors            []string
+		// State is the state file of the base branch, it's nil without three-way merge
+		State *State
+	}
+
+	SchemaError struct {
+		Filename    string
+		Field       string
+		Description string
+	}
+
+	// MergeConflict is a path which was changed differently in the template and the remote file.
+	MergeConflict struct {
+		Filename string
+		Path     string
+	}
+
+	// State records the last applied template of every file of a repository. It's committed together with the files.
+	State struct {
+		Files map[string]string `yaml:"files,omitempty" json:"files,omitempty"`
+		// Remote is the state file of the base branch, it's nil when the file doesn't exist
+		Remote *RemoteFile `yaml:"-" json:"-"`
 	}
 
 	RepositoryFileUpdate struct {
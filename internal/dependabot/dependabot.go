@@ -1,8 +1,13 @@
 package dependabot
 
+import "ghconfig/internal/common"
+
 type GithubDependabot struct {
 	Version string     `yaml:"version,omitempty" json:"version,omitempty"`
 	Updates []*Updates `yaml:"updates,omitempty" json:"updates,omitempty"`
+
+	// Merge selects the merge strategy of paths in the local template e.g `/updates/*/labels: append`
+	Merge common.MergeStrategies `yaml:"$merge,omitempty" json:"-"`
 }
 type Schedule struct {
 	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
@@ -10,6 +15,9 @@ type Schedule struct {
 type Ignore struct {
 	DependencyName string   `yaml:"dependency-name,omitempty" json:"dependency-name,omitempty"`
 	Versions       []string `yaml:"versions,omitempty" json:"versions,omitempty"`
+
+	// Delete removes the ignore entry of the dependency from the remote file
+	Delete bool `yaml:"$delete,omitempty" json:"-"`
 }
 
 type PullRequestBranchName struct {
@@ -41,6 +49,9 @@ type Updates struct {
 	CommitMessage         CommitMessage `yaml:"commit-message,omitempty" json:"commit-message,omitempty"`
 	Assignees             []string      `yaml:"assignees,omitempty" json:"assignees,omitempty"`
 	Reviewers             []string      `yaml:"reviewers,omitempty" json:"reviewers,omitempty"`
-	TargetBranch          []string      `yaml:"target-branch,omitempty" json:"target-branch,omitempty"`
-	VersioningStrategy    []string      `yaml:"versioning-strategy,omitempty" json:"versioning-strategy,omitempty"`
+	TargetBranch          string        `yaml:"target-branch,omitempty" json:"target-branch,omitempty"`
+	VersioningStrategy    string        `yaml:"versioning-strategy,omitempty" json:"versioning-strategy,omitempty"`
+
+	// Delete removes the update with the same directory and package ecosystem from the remote file
+	Delete bool `yaml:"$delete,omitempty" json:"-"`
 }
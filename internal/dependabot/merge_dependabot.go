@@ -23,14 +23,24 @@ func (t dependabotTransformer) Transformer(typ reflect.Type) func(dst, src refle
 					return fmt.Errorf("expect src to be type of Jobs, actual: %s", reflect.TypeOf(src).Name())
 				}
 
+				// the updates of the remote file keep their order, new updates are appended
+				updates := []*Updates{}
+				for _, dj := range dstUpdates {
+					sj := findUpdate(srcUpdates, dj)
+					switch {
+					case sj == nil:
+						updates = append(updates, dj)
+					case !sj.Delete:
+						mergeUpdates(sj, dj)
+						updates = append(updates, sj)
+					}
+				}
 				for _, sj := range srcUpdates {
-					for _, dj := range dstUpdates {
-						if (sj.Directory == dj.Directory) && (sj.PackageEcosystem == dj.PackageEcosystem) {
-							mergeUpdates(sj, dj)
-						}
+					if !sj.Delete && findUpdate(dstUpdates, sj) == nil {
+						updates = append(updates, sj)
 					}
 				}
-				dst.Set(reflect.ValueOf(srcUpdates))
+				dst.Set(reflect.ValueOf(updates))
 			}
 			return nil
 		}
@@ -39,7 +49,46 @@ func (t dependabotTransformer) Transformer(typ reflect.Type) func(dst, src refle
 }
 
 func MergeDependabot(dst *GithubDependabot, src GithubDependabot) error {
-	return mergo.MergeWithOverwrite(dst, src, mergo.WithTypeCheck, mergo.WithTransformers(dependabotTransformer{}))
+	strategies := src.Merge
+	src.Merge = nil
+
+	// the merge modifies both files, keep the original values for the strategies
+	var remote, local interface{}
+	if len(strategies) > 0 {
+		var err error
+		if remote, err = common.ToTree(dst); err != nil {
+			return err
+		}
+		if local, err = common.ToTree(src); err != nil {
+			return err
+		}
+	}
+
+	err := mergo.MergeWithOverwrite(dst, src, mergo.WithTypeCheck, mergo.WithTransformers(dependabotTransformer{}))
+	if err != nil {
+		return err
+	}
+
+	err = common.ApplyStrategies(dst, remote, local, strategies)
+	if err != nil {
+		return err
+	}
+
+	RemoveDeleteMarkers(dst)
+	return nil
+}
+
+func isSameUpdate(a, b *Updates) bool {
+	return (a.Directory == b.Directory) && (a.PackageEcosystem == b.PackageEcosystem)
+}
+
+func findUpdate(list []*Updates, update *Updates) *Updates {
+	for _, u := range list {
+		if isSameUpdate(u, update) {
+			return u
+		}
+	}
+	return nil
 }
 
 func mergeUpdates(src, dst *Updates) {
@@ -67,21 +116,15 @@ func mergeUpdates(src, dst *Updates) {
 
 	if len(src.Ignore) == 0 {
 		src.Ignore = dst.Ignore
-	}
-	for _, srcIgnore := range src.Ignore {
-		for _, dstIgnore := range dst.Ignore {
-			if srcIgnore.DependencyName == dstIgnore.DependencyName {
-				srcIgnore.Versions = common.Unique(srcIgnore.Versions, dstIgnore.Versions)
-				break
-			}
-		}
+	} else {
+		src.Ignore = mergeIgnore(src.Ignore, dst.Ignore)
 	}
 
 	src.Assignees = common.Unique(src.Assignees, dst.Assignees)
 	src.Labels = common.Unique(src.Labels, dst.Labels)
 	src.Reviewers = common.Unique(src.Reviewers, dst.Reviewers)
-	src.TargetBranch = common.Unique(src.TargetBranch, dst.TargetBranch)
-	src.VersioningStrategy = common.Unique(src.VersioningStrategy, dst.VersioningStrategy)
+	src.TargetBranch = common.MergeString(src.TargetBranch, dst.TargetBranch)
+	src.VersioningStrategy = common.MergeString(src.VersioningStrategy, dst.VersioningStrategy)
 
 	if len(src.Allow) == 0 {
 		src.Allow = dst.Allow
@@ -97,3 +140,73 @@ func mergeUpdates(src, dst *Updates) {
 	}
 
 }
+
+// mergeIgnore merges the ignore entries of the same dependency. The entries of dst keep their order,
+// new entries of src are appended and entries marked with `$delete` are removed.
+func mergeIgnore(src, dst []*Ignore) []*Ignore {
+	ignore := []*Ignore{}
+	for _, dstIgnore := range dst {
+		srcIgnore := findIgnore(src, dstIgnore)
+		switch {
+		case srcIgnore == nil:
+			ignore = append(ignore, dstIgnore)
+		case !srcIgnore.Delete:
+			srcIgnore.Versions = common.Unique(srcIgnore.Versions, dstIgnore.Versions)
+			ignore = append(ignore, srcIgnore)
+		}
+	}
+	for _, srcIgnore := range src {
+		if !srcIgnore.Delete && findIgnore(dst, srcIgnore) == nil {
+			ignore = append(ignore, srcIgnore)
+		}
+	}
+	return ignore
+}
+
+func findIgnore(list []*Ignore, ignore *Ignore) *Ignore {
+	for _, i := range list {
+		if i.DependencyName == ignore.DependencyName {
+			return i
+		}
+	}
+	return nil
+}
+
+// RemoveDeleteMarkers removes the delete markers which are left after a merge e.g. on fields which
+// don't exist in the remote file or when a file is created from a local template.
+func RemoveDeleteMarkers(d *GithubDependabot) {
+	updates := []*Updates{}
+	for _, u := range d.Updates {
+		if u.Delete {
+			continue
+		}
+		u.Milestone = common.MergeString(u.Milestone, "")
+		u.PullRequestBranchName = common.MergeString(u.PullRequestBranchName, "")
+		u.RebaseStrategy = common.MergeString(u.RebaseStrategy, "")
+		u.Schedule.Interval = common.MergeString(u.Schedule.Interval, "")
+		u.CommitMessage.Include = common.MergeString(u.CommitMessage.Include, "")
+		u.CommitMessage.Prefix = common.MergeString(u.CommitMessage.Prefix, "")
+		u.CommitMessage.PrefixDevelopment = common.MergeString(u.CommitMessage.PrefixDevelopment, "")
+		u.Labels = common.RemoveDeleted(u.Labels)
+		u.Assignees = common.RemoveDeleted(u.Assignees)
+		u.Reviewers = common.RemoveDeleted(u.Reviewers)
+		u.TargetBranch = common.MergeString(u.TargetBranch, "")
+		u.VersioningStrategy = common.MergeString(u.VersioningStrategy, "")
+
+		ignore := []*Ignore{}
+		for _, i := range u.Ignore {
+			if i.Delete {
+				continue
+			}
+			i.Versions = common.RemoveDeleted(i.Versions)
+			ignore = append(ignore, i)
+		}
+		if len(u.Ignore) > 0 {
+			u.Ignore = ignore
+		}
+		updates = append(updates, u)
+	}
+	if len(d.Updates) > 0 {
+		d.Updates = updates
+	}
+}
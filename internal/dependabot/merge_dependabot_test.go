@@ -19,7 +19,7 @@ func TestSync_MergeDependabot(t *testing.T) {
 	// src: templated local file
 	testcases := []testCase{
 		{
-			Description: "Dst is always overriden by Src",
+			Description: "Updates of Src are merged with the same updates of Dst, other updates of Dst are kept",
 			Dst: GithubDependabot{
 				Version: "1",
 				Updates: []*Updates{
@@ -69,15 +69,179 @@ func TestSync_MergeDependabot(t *testing.T) {
 							{DependencyName: "dep", Versions: []string{"1.0.0", "2.0.0"}},
 						},
 					},
+					{
+						Directory: "/bar",
+						Ignore: []*Ignore{
+							{DependencyName: "dep", Versions: []string{"1.0.0", "2.0.0"}},
+						},
+					},
+					{
+						Directory:        "/bar",
+						PackageEcosystem: "docker",
+					},
 					{
 						Directory: "/hello",
 					},
+				},
+			},
+		},
+		{
+			Description: "Mixed lists of new, changed and deleted updates and ignore entries",
+			Dst: GithubDependabot{
+				Version: "2",
+				Updates: []*Updates{
 					{
-						Directory: "/bar",
+						Directory:        "/",
+						PackageEcosystem: "npm",
 						Ignore: []*Ignore{
-							{DependencyName: "dep", Versions: []string{"1.0.0", "2.0.0"}},
+							{DependencyName: "lodash"},
+							{DependencyName: "react", Versions: []string{"16.x"}},
+							{DependencyName: "express"},
+						},
+					},
+					{
+						Directory:        "/",
+						PackageEcosystem: "docker",
+					},
+					{
+						Directory:        "/",
+						PackageEcosystem: "gomod",
+					},
+				},
+			},
+			Src: GithubDependabot{
+				Updates: []*Updates{
+					{
+						Directory:        "/",
+						PackageEcosystem: "docker",
+						Delete:           true,
+					},
+					{
+						Directory:        "/",
+						PackageEcosystem: "npm",
+						Ignore: []*Ignore{
+							{DependencyName: "lodash", Delete: true},
+							{DependencyName: "react", Versions: []string{"17.x"}},
+							{DependencyName: "vue"},
+						},
+					},
+					{
+						Directory:        "/",
+						PackageEcosystem: "github-actions",
+					},
+				},
+			},
+			Output: GithubDependabot{
+				Version: "2",
+				Updates: []*Updates{
+					{
+						Directory:        "/",
+						PackageEcosystem: "npm",
+						Ignore: []*Ignore{
+							{DependencyName: "react", Versions: []string{"16.x", "17.x"}},
+							{DependencyName: "express"},
+							{DependencyName: "vue"},
+						},
+					},
+					{
+						Directory:        "/",
+						PackageEcosystem: "gomod",
+					},
+					{
+						Directory:        "/",
+						PackageEcosystem: "github-actions",
+					},
+				},
+			},
+		},
+		{
+			Description: "Updates, ignore entries and list items marked with $delete are removed from Dst",
+			Dst: GithubDependabot{
+				Version: "2",
+				Updates: []*Updates{
+					{
+						Directory:        "/",
+						PackageEcosystem: "npm",
+						Labels:           []string{"deps", "npm"},
+						Ignore: []*Ignore{
+							{DependencyName: "lodash"},
+							{DependencyName: "react", Versions: []string{"16.x", "17.x"}},
 						},
 					},
+					{
+						Directory:        "/",
+						PackageEcosystem: "docker",
+					},
+				},
+			},
+			Src: GithubDependabot{
+				Updates: []*Updates{
+					{
+						Directory:        "/",
+						PackageEcosystem: "npm",
+						Labels:           []string{"$delete:npm"},
+						Milestone:        "$delete",
+						Ignore: []*Ignore{
+							{DependencyName: "lodash", Delete: true},
+						},
+					},
+					{
+						Directory:        "/",
+						PackageEcosystem: "docker",
+						Delete:           true,
+					},
+				},
+			},
+			Output: GithubDependabot{
+				Version: "2",
+				Updates: []*Updates{
+					{
+						Directory:        "/",
+						PackageEcosystem: "npm",
+						Labels:           []string{"deps"},
+						Ignore: []*Ignore{
+							{DependencyName: "react", Versions: []string{"16.x", "17.x"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			Description: "Lists keep the order of Dst and scalars of Src take precedence",
+			Dst: GithubDependabot{
+				Version: "2",
+				Updates: []*Updates{
+					{
+						Directory:          "/",
+						PackageEcosystem:   "npm",
+						Labels:             []string{"npm", "dependencies"},
+						Reviewers:          []string{"octocat"},
+						TargetBranch:       "develop",
+						VersioningStrategy: "increase",
+					},
+				},
+			},
+			Src: GithubDependabot{
+				Updates: []*Updates{
+					{
+						Directory:        "/",
+						PackageEcosystem: "npm",
+						Labels:           []string{"automerge", "npm"},
+						TargetBranch:     "main",
+					},
+				},
+			},
+			Output: GithubDependabot{
+				Version: "2",
+				Updates: []*Updates{
+					{
+						Directory:          "/",
+						PackageEcosystem:   "npm",
+						Labels:             []string{"npm", "dependencies", "automerge"},
+						Reviewers:          []string{"octocat"},
+						TargetBranch:       "main",
+						VersioningStrategy: "increase",
+					},
 				},
 			},
 		},
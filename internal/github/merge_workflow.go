@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"ghconfig/internal/common"
 	"reflect"
+	"strings"
 
 	"github.com/imdario/mergo"
 )
@@ -11,23 +12,7 @@ import (
 type workflowTransformer struct{}
 
 func (t workflowTransformer) Transformer(typ reflect.Type) func(dst, src reflect.Value) error {
-	if typ == reflect.TypeOf(Env{}) {
-		return func(dst, src reflect.Value) error {
-			if dst.CanSet() {
-				dstEnv, ok := dst.Interface().(Env)
-				if !ok {
-					return fmt.Errorf("expect dst to be type of Env, actual: %s", reflect.TypeOf(dstEnv).Name())
-				}
-				srcEnv, ok := src.Interface().(Env)
-				if !ok {
-					return fmt.Errorf("expect src to be type of Env, actual: %s", reflect.TypeOf(srcEnv).Name())
-				}
-				merge := common.MergeStringMap(srcEnv, dstEnv)
-				dst.Set(reflect.ValueOf(merge))
-			}
-			return nil
-		}
-	} else if typ == reflect.TypeOf(On{}) {
+	if typ == reflect.TypeOf(On{}) {
 		return func(dst, src reflect.Value) error {
 			if dst.CanSet() {
 				dstOn, ok := dst.Interface().(On)
@@ -39,19 +24,22 @@ func (t workflowTransformer) Transformer(typ reflect.Type) func(dst, src reflect
 					return fmt.Errorf("expect src to be type of *On, actual: %s", reflect.TypeOf(src).Name())
 				}
 
-				if srcOn.PageBuild == "" {
-					srcOn.PageBuild = dstOn.PageBuild
+				srcValue := reflect.ValueOf(&srcOn).Elem()
+				dstValue := reflect.ValueOf(dstOn)
+				for i := 0; i < srcValue.NumField(); i++ {
+					if srcValue.Field(i).Type() != eventType {
+						continue
+					}
+					srcEvent, _ := srcValue.Field(i).Interface().(*Event)
+					dstEvent, _ := dstValue.Field(i).Interface().(*Event)
+					srcValue.Field(i).Set(reflect.ValueOf(mergeEvent(srcEvent, dstEvent)))
+				}
+				srcOn.Extra = common.MergeMap(srcOn.Extra, dstOn.Extra)
+				// keep the notation and the order of the remote file e.g `on: [push]`
+				if !reflect.ValueOf(dstOn).IsZero() {
+					srcOn.shorthand = dstOn.shorthand
+					srcOn.order = mergeEventOrder(srcOn.order, dstOn.order)
 				}
-
-				srcOn.Release.Types = common.Unique(srcOn.Release.Types, dstOn.Release.Types)
-				srcOn.Push.Branches = common.Unique(srcOn.Push.Branches, dstOn.Push.Branches)
-				srcOn.Push.TagsIgnore = common.Unique(srcOn.Push.TagsIgnore, dstOn.Push.TagsIgnore)
-				srcOn.Push.Tags = common.Unique(srcOn.Push.Tags, dstOn.Push.Tags)
-				srcOn.Push.PathsIgnore = common.Unique(srcOn.Push.PathsIgnore, dstOn.Push.PathsIgnore)
-				srcOn.PullRequest.Branches = common.Unique(srcOn.PullRequest.Branches, dstOn.PullRequest.Branches)
-				srcOn.PullRequest.PathsIgnore = common.Unique(srcOn.PullRequest.PathsIgnore, dstOn.PullRequest.PathsIgnore)
-				srcOn.PullRequest.Tags = common.Unique(srcOn.PullRequest.Tags, dstOn.PullRequest.Tags)
-				srcOn.PullRequest.TagsIgnore = common.Unique(srcOn.PullRequest.TagsIgnore, dstOn.PullRequest.TagsIgnore)
 
 				if len(srcOn.Schedule) == 0 {
 					srcOn.Schedule = dstOn.Schedule
@@ -86,6 +74,10 @@ func (t workflowTransformer) Transformer(typ reflect.Type) func(dst, src reflect
 				}
 
 				for sk, sj := range srcJobs {
+					if sj.Delete {
+						dst.SetMapIndex(reflect.ValueOf(sk), reflect.Value{})
+						continue
+					}
 					for dk, dj := range dstJobs {
 						if sk == dk {
 							mergeJobs(sj, dj)
@@ -102,27 +94,121 @@ func (t workflowTransformer) Transformer(typ reflect.Type) func(dst, src reflect
 }
 
 func MergeWorkflow(dst *GithubWorkflow, src GithubWorkflow) error {
-	return mergo.MergeWithOverwrite(dst, src,
+	strategies := src.Merge
+	src.Merge = nil
+
+	// the merge modifies both files, keep the original values for the strategies
+	var remote, local interface{}
+	if len(strategies) > 0 {
+		var err error
+		if remote, err = common.ToTree(dst); err != nil {
+			return err
+		}
+		if local, err = common.ToTree(src); err != nil {
+			return err
+		}
+	}
+
+	// mergo replaces interface values as a whole and keeps delete markers in maps, maps are merged by key
+	env := mergeEnv(src.Env, dst.Env)
+	permissions := mergeMapValue(src.Permissions, dst.Permissions)
+	concurrency := mergeMapValue(src.Concurrency, dst.Concurrency)
+
+	err := mergo.MergeWithOverwrite(dst, src,
 		mergo.WithTypeCheck,
 		mergo.WithTransformers(workflowTransformer{}),
 	)
+	if err != nil {
+		return err
+	}
+	dst.Env = env
+	dst.Permissions = permissions
+	dst.Concurrency = concurrency
+
+	err = common.ApplyStrategies(dst, remote, local, strategies)
+	if err != nil {
+		return err
+	}
+
+	RemoveDeleteMarkers(dst)
+	return nil
+}
+
+// mergeEventOrder returns the events of dst followed by the events of src which are not part of dst.
+func mergeEventOrder(src, dst []string) []string {
+	if len(src) == 0 {
+		return dst
+	}
+	order := append([]string{}, dst...)
+	known := map[string]bool{}
+	for _, name := range dst {
+		known[name] = true
+	}
+	for _, name := range src {
+		if !known[name] {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+func mergeEvent(src, dst *Event) *Event {
+	if src == nil {
+		return dst
+	}
+	if src.Delete {
+		return nil
+	}
+	if dst == nil {
+		return src
+	}
+
+	src.Types = common.Unique(src.Types, dst.Types)
+	src.Branches = common.Unique(src.Branches, dst.Branches)
+	src.BranchesIgnore = common.Unique(src.BranchesIgnore, dst.BranchesIgnore)
+	src.Tags = common.Unique(src.Tags, dst.Tags)
+	src.TagsIgnore = common.Unique(src.TagsIgnore, dst.TagsIgnore)
+	src.Paths = common.Unique(src.Paths, dst.Paths)
+	src.PathsIgnore = common.Unique(src.PathsIgnore, dst.PathsIgnore)
+	src.Workflows = common.Unique(src.Workflows, dst.Workflows)
+	src.Inputs = common.MergeMap(src.Inputs, dst.Inputs)
+	src.Outputs = common.MergeMap(src.Outputs, dst.Outputs)
+	src.Secrets = common.MergeMap(src.Secrets, dst.Secrets)
+	src.Extra = common.MergeMap(src.Extra, dst.Extra)
+
+	return src
 }
 
 func mergeJobs(src, dst *Job) {
-	src.Env = common.MergeStringMap(src.Env, dst.Env)
+	src.Env = mergeMapValue(src.Env, dst.Env)
 	src.Needs = common.Unique(src.Needs, dst.Needs)
 	src.Outputs = common.MergeStringMap(src.Outputs, dst.Outputs)
 
-	if src.RunsOn == "" {
-		src.RunsOn = dst.RunsOn
+	src.With = common.MergeMap(src.With, dst.With)
+	src.Extra = common.MergeMap(src.Extra, dst.Extra)
+
+	if src.Name == "" {
+		src.Name = dst.Name
+	}
+	src.RunsOn = mergeValue(src.RunsOn, dst.RunsOn)
+	src.Permissions = mergeMapValue(src.Permissions, dst.Permissions)
+	if src.Environment == nil {
+		src.Environment = dst.Environment
 	}
-	if src.RunsOn == "" {
-		src.RunsOn = dst.RunsOn
+	src.Concurrency = mergeMapValue(src.Concurrency, dst.Concurrency)
+	if src.Secrets == nil {
+		src.Secrets = dst.Secrets
+	}
+	if src.Uses == "" {
+		src.Uses = dst.Uses
+	}
+	if src.TimeoutMinutes == nil {
+		src.TimeoutMinutes = dst.TimeoutMinutes
 	}
 	if src.If == "" {
 		src.If = dst.If
 	}
-	if !src.ContinueOnError {
+	if src.ContinueOnError == nil {
 		src.ContinueOnError = dst.ContinueOnError
 	}
 	if src.Defaults.Run.WorkingDirectory == "" {
@@ -156,116 +242,520 @@ func isSameStep(a, b *Step) bool {
 	if a.Name != "" && b.Name != "" {
 		return a.Name == b.Name
 	}
-	if reflect.DeepEqual(a, b) {
-		return true
+	if a.Uses != "" && b.Uses != "" {
+		return actionName(a.Uses) == actionName(b.Uses)
 	}
-	return false
+	x, y := *a, *b
+	x.Delete, x.Before, x.After = false, "", ""
+	y.Delete, y.Before, y.After = false, "", ""
+	return reflect.DeepEqual(x, y)
 }
 
+// actionName returns the action of a step without the version e.g `actions/checkout`.
+func actionName(uses string) string {
+	return strings.SplitN(uses, "@", 2)[0]
+}
+
+// isStepAnchor reports whether the step is referenced by the id, name or action of a `$before` or `$after` anchor.
+func isStepAnchor(step *Step, anchor string) bool {
+	return (step.ID != "" && step.ID == anchor) ||
+		(step.Name != "" && step.Name == anchor) ||
+		(step.Uses != "" && actionName(step.Uses) == actionName(anchor))
+}
+
+// mergeJobSteps merges the steps of src into the steps of dst. The order of the remote steps is never changed
+// and remote only steps are kept. New steps are inserted at their anchor (`$before`, `$after`) or after the
+// previous step of the local template.
 func mergeJobSteps(src, dst *Job) {
-	for _, sStep := range src.Steps {
-		for _, dStep := range dst.Steps {
-			if isSameStep(sStep, dStep) {
-				if len(sStep.With) == 0 {
-					sStep.With = dStep.With
-				}
-				if sStep.Name == "" {
-					sStep.Name = dStep.Name
-				}
-				if sStep.If == "" {
-					sStep.If = dStep.If
-				}
-				if sStep.Run == "" {
-					sStep.Run = dStep.Run
-				}
-				if sStep.ID == "" {
-					sStep.ID = dStep.ID
-				}
-				if sStep.Uses == "" {
-					sStep.Uses = dStep.Uses
-				}
-				if sStep.TimeoutMinutes == 0 {
-					sStep.TimeoutMinutes = dStep.TimeoutMinutes
-				}
-				if !sStep.ContinueOnError {
-					sStep.ContinueOnError = dStep.ContinueOnError
+	steps := append([]*Step{}, dst.Steps...)
+	matched := make([]bool, len(steps))
+	// the position of the previous local step in the result
+	previous := -1
+
+	for i, sStep := range src.Steps {
+		j := findStep(steps, matched, sStep)
+		if sStep.Delete {
+			if j >= 0 {
+				steps = append(steps[:j], steps[j+1:]...)
+				matched = append(matched[:j], matched[j+1:]...)
+				if previous >= j {
+					previous--
 				}
-				break
 			}
+			continue
+		}
+		if j >= 0 {
+			mergeStep(sStep, steps[j])
+			steps[j] = sStep
+			matched[j] = true
+			previous = j
+			continue
+		}
+
+		position := stepPosition(steps, src.Steps[i+1:], matched, sStep, previous)
+		steps = append(steps[:position], append([]*Step{sStep}, steps[position:]...)...)
+		matched = append(matched[:position], append([]bool{true}, matched[position:]...)...)
+		previous = position
+	}
+
+	src.Steps = steps
+}
+
+func findStep(steps []*Step, matched []bool, step *Step) int {
+	for j, candidate := range steps {
+		if !matched[j] && isSameStep(step, candidate) {
+			return j
+		}
+	}
+	return -1
+}
+
+// stepPosition returns the index at which a new step is inserted.
+func stepPosition(steps []*Step, next []*Step, matched []bool, step *Step, previous int) int {
+	for j, candidate := range steps {
+		if step.Before != "" && isStepAnchor(candidate, step.Before) {
+			return j
+		}
+		if step.After != "" && isStepAnchor(candidate, step.After) {
+			return j + 1
 		}
 	}
+	if previous >= 0 {
+		return previous + 1
+	}
+	// the step is in front of the first local step which exists on the remote
+	for _, nStep := range next {
+		if j := findStep(steps, matched, nStep); j >= 0 {
+			return j
+		}
+	}
+	return len(steps)
+}
+
+func mergeStep(sStep, dStep *Step) {
+	sStep.With = common.MergeMap(sStep.With, dStep.With)
+	if sStep.Name == "" {
+		sStep.Name = dStep.Name
+	}
+	if sStep.If == "" {
+		sStep.If = dStep.If
+	}
+	if sStep.Run == "" {
+		sStep.Run = dStep.Run
+	}
+	if sStep.ID == "" {
+		sStep.ID = dStep.ID
+	}
+	if sStep.Uses == "" {
+		sStep.Uses = dStep.Uses
+	}
+	if sStep.Shell == "" {
+		sStep.Shell = dStep.Shell
+	}
+	if sStep.WorkingDirectory == "" {
+		sStep.WorkingDirectory = dStep.WorkingDirectory
+	}
+	sStep.Env = mergeMapValue(sStep.Env, dStep.Env)
+	sStep.Extra = common.MergeMap(sStep.Extra, dStep.Extra)
+	if sStep.TimeoutMinutes == nil {
+		sStep.TimeoutMinutes = dStep.TimeoutMinutes
+	}
+	if sStep.ContinueOnError == nil {
+		sStep.ContinueOnError = dStep.ContinueOnError
+	}
+}
+
+// mergeEnv merges the env of the workflow. An env without variables is removed.
+func mergeEnv(src, dst Env) Env {
+	env := common.MergeMap(src, dst)
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+// mergeMapValue merges values which are maps e.g the env of jobs and steps, permissions or concurrency.
+// An expression e.g `${{ fromJson(..) }}` or a scalar e.g `permissions: read-all` can't be merged and
+// replaces the other value.
+func mergeMapValue(src, dst interface{}) interface{} {
+	srcMap, srcIsMap := src.(map[string]interface{})
+	dstMap, dstIsMap := dst.(map[string]interface{})
+	if !srcIsMap || (dst != nil && !dstIsMap) {
+		return mergeValue(src, dst)
+	}
+	// an empty map is a value of its own e.g `permissions: {}` disables all permissions
+	if len(srcMap) == 0 && dst == nil {
+		return src
+	}
+	merged := common.MergeMap(srcMap, dstMap)
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
 }
 
 func mergeJobStrategy(src, dst *Job) {
-	if !src.Strategy.FailFast {
+	if src.Strategy.FailFast == nil {
 		src.Strategy.FailFast = dst.Strategy.FailFast
 	}
 
-	if src.Strategy.MaxParallel == 0 {
+	if src.Strategy.MaxParallel == nil {
 		src.Strategy.MaxParallel = dst.Strategy.MaxParallel
 	}
 
-	if len(src.Strategy.Matrix) == 0 {
+	if src.Strategy.Matrix == nil {
 		src.Strategy.Matrix = dst.Strategy.Matrix
 		return
 	}
 
-	for srcKey, srcVal := range src.Strategy.Matrix {
-		for dstKey, dstVal := range dst.Strategy.Matrix {
-			if srcKey == dstKey {
-				switch srcValType := srcVal.(type) {
-				case []interface{}:
-					stringArray := []interface{}{}
-					newArray := []string{}
-					if d, ok := dstVal.([]interface{}); ok {
-						for _, a := range d {
-							if av, ok := a.(string); ok {
-								stringArray = append(stringArray, av)
-							}
-						}
-						if len(stringArray) > 0 {
-							stringArray = append(stringArray, srcValType...)
-							for _, l := range stringArray {
-								newArray = append(newArray, fmt.Sprintf("%v", l))
-							}
-							src.Strategy.Matrix[srcKey] = common.Unique(newArray, []string{})
-						}
-					}
-				default:
-					if !reflect.ValueOf(srcValType).IsZero() {
-						src.Strategy.Matrix[srcKey] = dstVal
-					}
-				}
-				break
+	srcMatrix, ok := src.Strategy.Matrix.(Matrix)
+	if !ok {
+		// the matrix is generated by an expression e.g `${{ fromJson(needs.setup.outputs.matrix) }}`
+		return
+	}
+	dstMatrix, ok := dst.Strategy.Matrix.(Matrix)
+	if !ok {
+		return
+	}
+
+	// the dimensions of the matrix identify entries of include and exclude
+	dimensions := []string{}
+	for _, matrix := range []Matrix{srcMatrix, dstMatrix} {
+		for key := range matrix {
+			if key != "include" && key != "exclude" {
+				dimensions = append(dimensions, key)
+			}
+		}
+	}
+	dimensions = common.Unique(dimensions, nil)
+
+	for dstKey, dstVal := range dstMatrix {
+		srcVal, ok := srcMatrix[dstKey]
+		if !ok {
+			srcMatrix[dstKey] = dstVal
+			continue
+		}
+
+		srcList, srcIsList := srcVal.([]interface{})
+		dstList, dstIsList := dstVal.([]interface{})
+		switch {
+		case srcIsList && dstIsList && (dstKey == "include" || dstKey == "exclude"):
+			srcMatrix[dstKey] = mergeMatrixEntries(srcList, dstList, dimensions)
+		case srcIsList && dstIsList:
+			srcMatrix[dstKey] = mergeMatrixValues(srcList, dstList)
+			if srcMatrix[dstKey] == nil {
+				delete(srcMatrix, dstKey)
 			}
+		case srcVal == nil:
+			srcMatrix[dstKey] = dstVal
 		}
 	}
 }
 
-func mergeJobContainer(src, dst *Job) {
-	src.Container.Env = common.MergeStringMap(src.Container.Env, dst.Container.Env)
-	src.Container.Ports = common.Unique(src.Container.Ports, dst.Container.Ports)
-	src.Container.Volumes = common.MergeStringMap(src.Container.Volumes, dst.Container.Volumes)
-	if src.Container.Image == "" {
-		src.Container.Image = dst.Container.Image
+// mergeMatrixValues returns the values of dst followed by the values of src which are not part of dst.
+// Values keep their type so that `[14, 16]` isn't converted to strings. Values marked with
+// `$delete:<value>` are removed.
+func mergeMatrixValues(src, dst []interface{}) []interface{} {
+	return common.UniqueValues(src, dst)
+}
+
+// mergeMatrixEntries merges include or exclude entries. Entries which refer to the same combination
+// of the matrix dimensions are merged, the fields of src take precedence. The entries of dst keep their
+// order, new entries of src are appended.
+func mergeMatrixEntries(src, dst []interface{}, dimensions []string) []interface{} {
+	list := append([]interface{}{}, dst...)
+	for _, srcEntry := range src {
+		i := indexMatrixValue(list, srcEntry, dimensions)
+		if i < 0 {
+			list = append(list, srcEntry)
+			continue
+		}
+		if reflect.DeepEqual(list[i], srcEntry) {
+			continue
+		}
+		srcFields, srcOk := matrixEntry(srcEntry)
+		dstFields, dstOk := matrixEntry(list[i])
+		if srcOk && dstOk {
+			entry := map[string]interface{}{}
+			for k, v := range dstFields {
+				entry[k] = v
+			}
+			for k, v := range srcFields {
+				entry[k] = v
+			}
+			list[i] = entry
+		}
 	}
+	return list
 }
 
+func indexMatrixValue(list []interface{}, value interface{}, dimensions []string) int {
+	for i, item := range list {
+		if reflect.DeepEqual(item, value) {
+			return i
+		}
+	}
+	if len(dimensions) == 0 {
+		return -1
+	}
+	identity := matrixEntryIdentity(value, dimensions)
+	if identity == "" {
+		return -1
+	}
+	for i, item := range list {
+		if matrixEntryIdentity(item, dimensions) == identity {
+			return i
+		}
+	}
+	return -1
+}
+
+// matrixEntryIdentity returns the values of all matrix dimensions of an include or exclude entry.
+func matrixEntryIdentity(value interface{}, dimensions []string) string {
+	fields, ok := matrixEntry(value)
+	if !ok {
+		return ""
+	}
+	identity := ""
+	for _, dimension := range dimensions {
+		if v, ok := fields[dimension]; ok {
+			identity += fmt.Sprintf("%s=%v;", dimension, v)
+		}
+	}
+	return identity
+}
+
+func matrixEntry(value interface{}) (map[string]interface{}, bool) {
+	switch entry := value.(type) {
+	case map[string]interface{}:
+		return entry, true
+	case map[string]string:
+		fields := map[string]interface{}{}
+		for k, v := range entry {
+			fields[k] = v
+		}
+		return fields, true
+	}
+	return nil, false
+}
+
+func mergeJobContainer(src, dst *Job) {
+	mergeContainer(&src.Container, &dst.Container)
+}
+
+// mergeJobServices merges services with the same name. Services which only exist in dst are kept.
 func mergeJobServices(src, dst *Job) {
+	services := Services{}
+	for dstKey, dstSvc := range dst.Services {
+		services[dstKey] = dstSvc
+	}
 	for srcKey, srcSvc := range src.Services {
-		for dstKey, dstSvc := range dst.Services {
-			if srcKey == dstKey {
-				srcSvc.Ports = common.Unique(srcSvc.Ports, dstSvc.Ports)
-				srcSvc.Env = common.MergeStringMap(srcSvc.Env, dstSvc.Env)
-				srcSvc.Volumes = common.MergeStringMap(srcSvc.Volumes, dstSvc.Volumes)
-				if srcSvc.Image == "" {
-					srcSvc.Image = dstSvc.Image
-				}
-				if srcSvc.Options == "" {
-					srcSvc.Options = dstSvc.Options
-				}
-				break
-			}
+		if srcSvc.Delete {
+			delete(services, srcKey)
+			continue
+		}
+		if dstSvc, ok := dst.Services[srcKey]; ok {
+			mergeContainer(srcSvc, dstSvc)
+		}
+		services[srcKey] = srcSvc
+	}
+	src.Services = services
+}
+
+func mergeContainer(src, dst *Container) {
+	if src.Delete {
+		*src = Container{}
+		return
+	}
+	if reflect.DeepEqual(*dst, Container{}) {
+		return
+	}
+
+	src.Env = common.MergeMap(src.Env, dst.Env)
+	src.Ports = common.UniqueValues(src.Ports, dst.Ports)
+	src.Volumes = common.Unique(src.Volumes, dst.Volumes)
+	src.Extra = common.MergeMap(src.Extra, dst.Extra)
+
+	if src.Image == "" {
+		src.Image = dst.Image
+	}
+	if src.Options == "" {
+		src.Options = dst.Options
+	}
+	if src.Credentials == nil {
+		src.Credentials = dst.Credentials
+	} else if dst.Credentials != nil {
+		if src.Credentials.Username == "" {
+			src.Credentials.Username = dst.Credentials.Username
+		}
+		if src.Credentials.Password == "" {
+			src.Credentials.Password = dst.Credentials.Password
+		}
+		src.Credentials.Extra = common.MergeMap(src.Credentials.Extra, dst.Credentials.Extra)
+	}
+	// keep the notation of the remote file e.g `container: node:14`
+	src.shorthand = dst.shorthand
+}
+
+// RemoveDeleteMarkers removes the delete markers which are left after a merge e.g. on fields which
+// don't exist in the remote file or when a file is created from a local template.
+func RemoveDeleteMarkers(w *GithubWorkflow) {
+	w.Name = common.MergeString(w.Name, "")
+	w.RunName = common.MergeString(w.RunName, "")
+	w.Permissions = mergeMapValue(w.Permissions, nil)
+	w.Concurrency = mergeMapValue(w.Concurrency, nil)
+	w.Env = mergeEnv(w.Env, nil)
+	w.Defaults.Run.Shell = common.MergeString(w.Defaults.Run.Shell, "")
+	w.Defaults.Run.WorkingDirectory = common.MergeString(w.Defaults.Run.WorkingDirectory, "")
+	w.Extra = common.MergeMap(w.Extra, nil)
+
+	onValue := reflect.ValueOf(&w.On).Elem()
+	for i := 0; i < onValue.NumField(); i++ {
+		if onValue.Field(i).Type() != eventType || onValue.Field(i).IsNil() {
+			continue
+		}
+		event := onValue.Field(i).Interface().(*Event)
+		if event.Delete {
+			onValue.Field(i).Set(reflect.Zero(eventType))
+			continue
+		}
+		removeEventDeleteMarkers(event)
+	}
+	w.On.Extra = common.MergeMap(w.On.Extra, nil)
+
+	for name, job := range w.Jobs {
+		if job.Delete {
+			delete(w.Jobs, name)
+			continue
+		}
+		removeJobDeleteMarkers(job)
+	}
+}
+
+// mergeValue returns src or dst when src is nil. The delete marker results in nil. A list which
+// contains `$delete:<value>` items is merged with the values of dst e.g `runs-on: [self-hosted, $delete:linux]`.
+func mergeValue(src, dst interface{}) interface{} {
+	if common.IsDeleted(src) {
+		return nil
+	}
+	if src == nil {
+		return dst
+	}
+	if list, ok := src.([]interface{}); ok && hasDeletedValues(list) {
+		dstList, ok := dst.([]interface{})
+		if !ok && dst != nil {
+			dstList = []interface{}{dst}
+		}
+		if values := common.UniqueValues(list, dstList); values != nil {
+			return values
 		}
+		return nil
 	}
+	return src
+}
+
+func hasDeletedValues(list []interface{}) bool {
+	return len(common.RemoveDeletedValues(list)) != len(list)
+}
+
+func removeEventDeleteMarkers(e *Event) {
+	e.Types = common.RemoveDeleted(e.Types)
+	e.Branches = common.RemoveDeleted(e.Branches)
+	e.BranchesIgnore = common.RemoveDeleted(e.BranchesIgnore)
+	e.Tags = common.RemoveDeleted(e.Tags)
+	e.TagsIgnore = common.RemoveDeleted(e.TagsIgnore)
+	e.Paths = common.RemoveDeleted(e.Paths)
+	e.PathsIgnore = common.RemoveDeleted(e.PathsIgnore)
+	e.Workflows = common.RemoveDeleted(e.Workflows)
+	e.Inputs = common.MergeMap(e.Inputs, nil)
+	e.Outputs = common.MergeMap(e.Outputs, nil)
+	e.Secrets = common.MergeMap(e.Secrets, nil)
+	e.Extra = common.MergeMap(e.Extra, nil)
+}
+
+func removeJobDeleteMarkers(j *Job) {
+	j.Name = common.MergeString(j.Name, "")
+	j.RunsOn = mergeValue(j.RunsOn, nil)
+	j.Permissions = mergeMapValue(j.Permissions, nil)
+	j.Environment = mergeValue(j.Environment, nil)
+	j.Concurrency = mergeMapValue(j.Concurrency, nil)
+	j.Secrets = mergeValue(j.Secrets, nil)
+	j.Uses = common.MergeString(j.Uses, "")
+	j.If = common.MergeString(j.If, "")
+	j.Defaults.Run.Shell = common.MergeString(j.Defaults.Run.Shell, "")
+	j.Defaults.Run.WorkingDirectory = common.MergeString(j.Defaults.Run.WorkingDirectory, "")
+	j.Env = mergeMapValue(j.Env, nil)
+	j.TimeoutMinutes = mergeValue(j.TimeoutMinutes, nil)
+	j.ContinueOnError = mergeValue(j.ContinueOnError, nil)
+	j.Strategy.MaxParallel = mergeValue(j.Strategy.MaxParallel, nil)
+	j.Strategy.FailFast = mergeValue(j.Strategy.FailFast, nil)
+	removeMatrixDeleteMarkers(j.Strategy.Matrix)
+	j.Outputs = common.MergeStringMap(j.Outputs, nil)
+	j.Needs = common.RemoveDeleted(j.Needs)
+	j.With = common.MergeMap(j.With, nil)
+	j.Extra = common.MergeMap(j.Extra, nil)
+
+	if j.Container.Delete {
+		j.Container = Container{}
+	}
+	removeContainerDeleteMarkers(&j.Container)
+	for name, service := range j.Services {
+		if service.Delete {
+			delete(j.Services, name)
+			continue
+		}
+		removeContainerDeleteMarkers(service)
+	}
+
+	steps := []*Step{}
+	for _, step := range j.Steps {
+		if step.Delete {
+			continue
+		}
+		step.Name = common.MergeString(step.Name, "")
+		step.ID = common.MergeString(step.ID, "")
+		step.If = common.MergeString(step.If, "")
+		step.Uses = common.MergeString(step.Uses, "")
+		step.Run = common.MergeString(step.Run, "")
+		step.Shell = common.MergeString(step.Shell, "")
+		step.WorkingDirectory = common.MergeString(step.WorkingDirectory, "")
+		step.With = common.MergeMap(step.With, nil)
+		step.Env = mergeMapValue(step.Env, nil)
+		step.TimeoutMinutes = mergeValue(step.TimeoutMinutes, nil)
+		step.ContinueOnError = mergeValue(step.ContinueOnError, nil)
+		step.Extra = common.MergeMap(step.Extra, nil)
+		step.Before, step.After = "", ""
+		steps = append(steps, step)
+	}
+	if len(j.Steps) > 0 {
+		j.Steps = steps
+	}
+}
+
+// removeMatrixDeleteMarkers removes the values marked with `$delete:<value>` from the matrix dimensions.
+func removeMatrixDeleteMarkers(m interface{}) {
+	matrix, ok := m.(Matrix)
+	if !ok {
+		return
+	}
+	for key, value := range matrix {
+		list, ok := value.([]interface{})
+		if !ok || key == "include" || key == "exclude" || !hasDeletedValues(list) {
+			continue
+		}
+		if values := common.RemoveDeletedValues(list); values != nil {
+			matrix[key] = values
+		} else {
+			delete(matrix, key)
+		}
+	}
+}
+
+func removeContainerDeleteMarkers(c *Container) {
+	c.Image = common.MergeString(c.Image, "")
+	c.Options = common.MergeString(c.Options, "")
+	c.Env = common.MergeMap(c.Env, nil)
+	c.Ports = common.RemoveDeletedValues(c.Ports)
+	c.Volumes = common.RemoveDeleted(c.Volumes)
+	c.Extra = common.MergeMap(c.Extra, nil)
 }
@@ -1,9 +1,11 @@
 package github
 
 import (
+	"ghconfig/internal/common"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 type testCase struct {
@@ -21,19 +23,19 @@ func TestSync_MergeWorkflow(t *testing.T) {
 		{
 			Description: "Primitive and array values are overriden by Src",
 			Dst: GithubWorkflow{
-				Env: map[string]string{
+				Env: map[string]interface{}{
 					"token": "123",
 				},
 				Name: "name_dst",
 			},
 			Src: GithubWorkflow{
-				Env: map[string]string{
+				Env: map[string]interface{}{
 					"existing": "223",
 				},
 				Name: "name_src",
 			},
 			Output: GithubWorkflow{
-				Env: map[string]string{
+				Env: map[string]interface{}{
 					"existing": "223",
 					"token":    "123",
 				},
@@ -112,7 +114,7 @@ func TestSync_MergeWorkflow(t *testing.T) {
 			},
 		},
 		{
-			Description: "Steps from same Job with the same (name or id or properties) are merged. No steps from dst are appended.",
+			Description: "Steps from same Job with the same (name or id or properties) are merged. Steps of dst are kept in their order and new steps are inserted after the previous step of src.",
 			Dst: GithubWorkflow{
 				Jobs: map[string]*Job{
 					"build": {
@@ -142,6 +144,7 @@ func TestSync_MergeWorkflow(t *testing.T) {
 						Steps: []*Step{
 							{Name: "install", ContinueOnError: true},
 							{Run: "npm ci"},
+							{Run: "npm test"},
 						},
 					},
 				},
@@ -178,15 +181,15 @@ func TestSync_MergeWorkflow(t *testing.T) {
 		{
 			Description: "Complex merge",
 			Dst: GithubWorkflow{
-				Env: map[string]string{
+				Env: map[string]interface{}{
 					"token": "123",
 				},
 				Name: "name_dst",
 				On: On{
-					Push: Push{
+					Push: &Push{
 						Branches: []string{"master"},
 					},
-					PullRequest: PullRequest{
+					PullRequest: &PullRequest{
 						Branches: []string{"master"},
 					},
 					Schedule: []Schedule{
@@ -198,8 +201,8 @@ func TestSync_MergeWorkflow(t *testing.T) {
 						Name: "build",
 						If:   "if",
 						Services: map[string]*Service{
-							"svc":  {Ports: []string{"8081", "9090"}},
-							"svc2": {Ports: []string{"8081", "9090"}},
+							"svc":  {Ports: []interface{}{"8081", "9090"}},
+							"svc2": {Ports: []interface{}{"8081", "9090"}},
 						},
 						Steps: []*Step{
 							{Name: "install", If: "if"},
@@ -222,12 +225,12 @@ func TestSync_MergeWorkflow(t *testing.T) {
 				},
 			},
 			Src: GithubWorkflow{
-				Env: map[string]string{
+				Env: map[string]interface{}{
 					"existing": "223",
 				},
 				On: On{
-					PageBuild: "pageBuild",
-					Push: Push{
+					PageBuild: &Event{},
+					Push: &Push{
 						Branches:    []string{"feature"},
 						PathsIgnore: []string{"docs/*"},
 					},
@@ -241,8 +244,8 @@ func TestSync_MergeWorkflow(t *testing.T) {
 					"build": {
 						Name: "build",
 						Services: map[string]*Service{
-							"svc":  {Ports: []string{"8080", "9090", "4040"}},
-							"svc3": {Ports: []string{"8081", "9090"}},
+							"svc":  {Ports: []interface{}{"8080", "9090", "4040"}},
+							"svc3": {Ports: []interface{}{"8081", "9090"}},
 						},
 						Steps: []*Step{
 							{Name: "install", ContinueOnError: true},
@@ -266,18 +269,18 @@ func TestSync_MergeWorkflow(t *testing.T) {
 			},
 
 			Output: GithubWorkflow{
-				Env: map[string]string{
+				Env: map[string]interface{}{
 					"existing": "223",
 					"token":    "123",
 				},
 				Name: "name_src",
 				On: On{
-					PageBuild: "pageBuild",
-					Push: Push{
-						Branches:    []string{"feature", "master"},
+					PageBuild: &Event{},
+					Push: &Push{
+						Branches:    []string{"master", "feature"},
 						PathsIgnore: []string{"docs/*"},
 					},
-					PullRequest: PullRequest{
+					PullRequest: &PullRequest{
 						Branches: []string{"master"},
 					},
 					Schedule: []Schedule{
@@ -291,18 +294,20 @@ func TestSync_MergeWorkflow(t *testing.T) {
 						Name: "build",
 						If:   "if",
 						Services: map[string]*Service{
-							"svc":  {Ports: []string{"4040", "8080", "8081", "9090"}},
-							"svc3": {Ports: []string{"8081", "9090"}},
+							"svc":  {Ports: []interface{}{"8081", "9090", "8080", "4040"}},
+							"svc2": {Ports: []interface{}{"8081", "9090"}},
+							"svc3": {Ports: []interface{}{"8081", "9090"}},
 						},
 						Steps: []*Step{
 							{Name: "install", ContinueOnError: true, If: "if"},
 							{Run: "npm test"},
 							{Name: "build"},
+							{Name: "not_added"},
 						},
 						Strategy: Strategy{
 							Matrix: Matrix{
-								"node-version": []string{"12.x", "13.x", "14.x"},
-								"os":           []string{"macOS-latest", "ubuntu-latest", "windows-latest"},
+								"node-version": []MatrixValue{"12.x", "14.x", "13.x"},
+								"os":           []MatrixValue{"ubuntu-latest", "windows-latest", "macOS-latest"},
 								"include": []MatrixValue{
 									map[string]string{"node": "12"},
 								},
@@ -318,6 +323,455 @@ func TestSync_MergeWorkflow(t *testing.T) {
 				},
 			},
 		},
+		{
+			Description: "Events, permissions, reusable workflows and unknown keys of dst are preserved",
+			Dst: GithubWorkflow{
+				Permissions: "read-all",
+				Extra:       Extra{"unknown": "dst"},
+				On: On{
+					WorkflowDispatch: &Event{},
+					WorkflowCall: &Event{
+						Inputs: map[string]interface{}{"version": map[string]interface{}{"type": "string"}},
+					},
+				},
+				Jobs: map[string]*Job{
+					"build": {
+						RunsOn:      []interface{}{"self-hosted", "linux"},
+						Environment: "production",
+						Concurrency: "build",
+						Extra:       Extra{"unknown": "dst"},
+						Steps: []*Step{
+							{Name: "test", Shell: "bash", WorkingDirectory: "./src"},
+						},
+					},
+					"call": {
+						Uses:    "org/repo/.github/workflows/build.yml@main",
+						Secrets: "inherit",
+					},
+				},
+			},
+			Src: GithubWorkflow{
+				Concurrency: "ci",
+				On: On{
+					Push: &Event{Branches: []string{"main"}},
+					WorkflowCall: &Event{
+						Secrets: map[string]interface{}{"token": map[string]interface{}{"required": true}},
+					},
+				},
+				Jobs: map[string]*Job{
+					"build": {
+						TimeoutMinutes: 30,
+						Steps: []*Step{
+							{Name: "test", Run: "make test", Env: map[string]interface{}{"CI": "true"}},
+						},
+					},
+					"call": {
+						With: map[string]interface{}{"version": 1},
+					},
+				},
+			},
+			Output: GithubWorkflow{
+				Permissions: "read-all",
+				Concurrency: "ci",
+				Extra:       Extra{"unknown": "dst"},
+				On: On{
+					Push:             &Event{Branches: []string{"main"}},
+					WorkflowDispatch: &Event{},
+					WorkflowCall: &Event{
+						Inputs:  map[string]interface{}{"version": map[string]interface{}{"type": "string"}},
+						Secrets: map[string]interface{}{"token": map[string]interface{}{"required": true}},
+					},
+				},
+				Jobs: map[string]*Job{
+					"build": {
+						RunsOn:         []interface{}{"self-hosted", "linux"},
+						Environment:    "production",
+						Concurrency:    "build",
+						TimeoutMinutes: 30,
+						Extra:          Extra{"unknown": "dst"},
+						Steps: []*Step{
+							{Name: "test", Run: "make test", Shell: "bash", WorkingDirectory: "./src", Env: map[string]interface{}{"CI": "true"}},
+						},
+					},
+					"call": {
+						Uses:    "org/repo/.github/workflows/build.yml@main",
+						With:    map[string]interface{}{"version": 1},
+						Secrets: "inherit",
+					},
+				},
+			},
+		},
+		{
+			Description: "Container and services are merged field by field",
+			Dst: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Container: Container{
+							Image:       "node:14",
+							Credentials: &Credentials{Username: "user", Password: "dst"},
+							Env:         ContainerEnv{"A": "dst"},
+							Volumes:     ContainerVolumes{"/data:/data"},
+							Options:     "--cpus 1",
+						},
+						Services: map[string]*Service{
+							"db": {Image: "postgres", Env: ContainerEnv{"USER": "dst"}},
+						},
+					},
+				},
+			},
+			Src: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Container: Container{
+							Image:       "node:16",
+							Credentials: &Credentials{Password: "src"},
+							Env:         ContainerEnv{"B": "src"},
+							Volumes:     ContainerVolumes{"/cache:/cache"},
+						},
+						Services: map[string]*Service{
+							"db": {Ports: []interface{}{"5432"}, Volumes: ContainerVolumes{"/db"}},
+						},
+					},
+				},
+			},
+			Output: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Container: Container{
+							Image:       "node:16",
+							Credentials: &Credentials{Username: "user", Password: "src"},
+							Env:         ContainerEnv{"A": "dst", "B": "src"},
+							Volumes:     ContainerVolumes{"/data:/data", "/cache:/cache"},
+							Options:     "--cpus 1",
+						},
+						Services: map[string]*Service{
+							"db": {
+								Image:   "postgres",
+								Env:     ContainerEnv{"USER": "dst"},
+								Ports:   []interface{}{"5432"},
+								Volumes: ContainerVolumes{"/db"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Description: "Matrix values keep their type and include/exclude entries are merged by the matrix dimensions",
+			Dst: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Strategy: Strategy{
+							Matrix: Matrix{
+								"node":         []MatrixValue{14, 16},
+								"experimental": []MatrixValue{false},
+								"include": []MatrixValue{
+									map[string]interface{}{"node": 16, "os": "windows-latest"},
+									map[string]interface{}{"node": 14, "npm": 6},
+								},
+								"exclude": []MatrixValue{
+									map[string]interface{}{"node": 14, "experimental": true},
+								},
+							},
+						},
+					},
+					"generated": {
+						Strategy: Strategy{
+							Matrix: Matrix{"node": []MatrixValue{14}},
+						},
+					},
+				},
+			},
+			Src: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Strategy: Strategy{
+							Matrix: Matrix{
+								"node": []MatrixValue{16, 18},
+								"include": []MatrixValue{
+									map[string]interface{}{"node": 16, "os": "macos-latest"},
+								},
+								"exclude": []MatrixValue{
+									map[string]interface{}{"node": 18, "experimental": true},
+								},
+							},
+						},
+					},
+					"generated": {
+						Strategy: Strategy{
+							Matrix: "${{ fromJson(needs.setup.outputs.matrix) }}",
+						},
+					},
+				},
+			},
+			Output: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Strategy: Strategy{
+							Matrix: Matrix{
+								"node":         []MatrixValue{14, 16, 18},
+								"experimental": []MatrixValue{false},
+								"include": []MatrixValue{
+									map[string]interface{}{"node": 16, "os": "macos-latest"},
+									map[string]interface{}{"node": 14, "npm": 6},
+								},
+								"exclude": []MatrixValue{
+									map[string]interface{}{"node": 14, "experimental": true},
+									map[string]interface{}{"node": 18, "experimental": true},
+								},
+							},
+						},
+					},
+					"generated": {
+						Strategy: Strategy{
+							Matrix: "${{ fromJson(needs.setup.outputs.matrix) }}",
+						},
+					},
+				},
+			},
+		},
+		{
+			Description: "Fields, jobs, steps and list items marked with $delete are removed from Dst",
+			Dst: GithubWorkflow{
+				Env: map[string]interface{}{"token": "123", "legacy": "1"},
+				On: On{
+					Push:        &Event{Branches: []string{"main", "master"}},
+					PullRequest: &Event{},
+				},
+				Jobs: map[string]*Job{
+					"build": {
+						If:  "always()",
+						Env: map[string]interface{}{"CI": "true", "OLD": "1"},
+						Steps: []*Step{
+							{Name: "checkout", Uses: "actions/checkout@v2"},
+							{Name: "legacy", Run: "make legacy"},
+							{Name: "test", Run: "make test", With: map[string]interface{}{"cache": "npm", "node": "12"}},
+						},
+						Services: map[string]*Service{
+							"redis":    {Image: "redis"},
+							"postgres": {Image: "postgres"},
+						},
+					},
+					"legacy": {
+						RunsOn: "ubuntu-latest",
+					},
+				},
+			},
+			Src: GithubWorkflow{
+				Env: map[string]interface{}{"legacy": "$delete"},
+				On: On{
+					Push:        &Event{Branches: []string{"$delete:master"}},
+					PullRequest: &Event{Delete: true},
+				},
+				Jobs: map[string]*Job{
+					"build": {
+						If:  "$delete",
+						Env: map[string]interface{}{"OLD": "$delete"},
+						Steps: []*Step{
+							{Name: "legacy", Delete: true},
+						},
+						Services: map[string]*Service{
+							"redis": {Delete: true},
+						},
+					},
+					"legacy": {Delete: true},
+				},
+			},
+			Output: GithubWorkflow{
+				Env: map[string]interface{}{"token": "123"},
+				On: On{
+					Push: &Event{Branches: []string{"main"}},
+				},
+				Jobs: map[string]*Job{
+					"build": {
+						Env: map[string]interface{}{"CI": "true"},
+						Steps: []*Step{
+							{Name: "checkout", Uses: "actions/checkout@v2"},
+							{Name: "test", Run: "make test", With: map[string]interface{}{"cache": "npm", "node": "12"}},
+						},
+						Services: map[string]*Service{
+							"postgres": {Image: "postgres"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Description: "Steps marked with $delete are removed and input keys marked with $delete are removed from merged steps",
+			Dst: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Steps: []*Step{
+							{Name: "legacy", Run: "make legacy"},
+							{Name: "test", Run: "make test", With: map[string]interface{}{"cache": "npm", "node": "12"}},
+						},
+					},
+				},
+			},
+			Src: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Steps: []*Step{
+							{Name: "legacy", Delete: true},
+							{Name: "test", With: map[string]interface{}{"cache": "$delete", "node": "14"}},
+						},
+					},
+				},
+			},
+			Output: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Steps: []*Step{
+							{Name: "test", Run: "make test", With: map[string]interface{}{"node": "14"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			Description: "New steps are inserted at their anchor or next to their neighbours without reordering the steps of dst",
+			Dst: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Steps: []*Step{
+							{Uses: "actions/checkout@v2"},
+							{Uses: "actions/setup-node@v1", With: map[string]interface{}{"node-version": "12"}},
+							{Name: "test", Run: "make test"},
+							{Name: "custom", Run: "make custom"},
+						},
+					},
+				},
+			},
+			Src: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Steps: []*Step{
+							{Name: "prepare", Run: "make prepare"},
+							{Uses: "actions/checkout@v3"},
+							{Name: "lint", Run: "make lint", After: "actions/setup-node"},
+							{Name: "cache", Uses: "actions/cache@v2", Before: "test"},
+							{Name: "test", Run: "make ci"},
+							{Name: "upload", Run: "make upload"},
+						},
+					},
+				},
+			},
+			Output: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Steps: []*Step{
+							{Name: "prepare", Run: "make prepare"},
+							{Uses: "actions/checkout@v3"},
+							{Uses: "actions/setup-node@v1", With: map[string]interface{}{"node-version": "12"}},
+							{Name: "lint", Run: "make lint"},
+							{Name: "cache", Uses: "actions/cache@v2"},
+							{Name: "test", Run: "make ci"},
+							{Name: "upload", Run: "make upload"},
+							{Name: "custom", Run: "make custom"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Description: "Delete markers without counterpart in Dst are dropped",
+			Dst:         GithubWorkflow{},
+			Src: GithubWorkflow{
+				Name: "$delete",
+				Env:  map[string]interface{}{"legacy": "$delete"},
+				Jobs: map[string]*Job{
+					"build": {
+						RunsOn: "ubuntu-latest",
+						Needs:  StringArray{"setup", "$delete:lint"},
+						Steps: []*Step{
+							{Name: "legacy", Delete: true},
+							{Name: "test", Run: "make test", If: "$delete"},
+						},
+					},
+					"legacy": {Delete: true},
+				},
+			},
+			Output: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						RunsOn: "ubuntu-latest",
+						Needs:  StringArray{"setup"},
+						Steps: []*Step{
+							{Name: "test", Run: "make test"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Description: "The job name and expressions of Dst are kept, fields of Src take precedence",
+			Dst: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Name:            "Build",
+						Env:             "${{ fromJson(needs.setup.outputs.env) }}",
+						ContinueOnError: "${{ matrix.experimental }}",
+						TimeoutMinutes:  "${{ inputs.timeout }}",
+						Strategy:        Strategy{FailFast: "${{ inputs.fail-fast }}", MaxParallel: 2},
+					},
+				},
+			},
+			Src: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						RunsOn:          "ubuntu-latest",
+						ContinueOnError: false,
+						Strategy:        Strategy{MaxParallel: "${{ inputs.parallel }}"},
+					},
+				},
+			},
+			Output: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Name:            "Build",
+						RunsOn:          "ubuntu-latest",
+						Env:             "${{ fromJson(needs.setup.outputs.env) }}",
+						ContinueOnError: false,
+						TimeoutMinutes:  "${{ inputs.timeout }}",
+						Strategy:        Strategy{FailFast: "${{ inputs.fail-fast }}", MaxParallel: "${{ inputs.parallel }}"},
+					},
+				},
+			},
+		},
+		{
+			Description: "Services of Dst are kept when Src declares other services, ports keep their type",
+			Dst: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Services: map[string]*Service{
+							"redis":    {Image: "redis", Ports: []interface{}{6379}},
+							"postgres": {Image: "postgres", Ports: []interface{}{5432, 5433}},
+						},
+					},
+				},
+			},
+			Src: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Services: map[string]*Service{
+							"postgres": {Ports: []interface{}{"$delete:5433", "5434:5432"}},
+							"mysql":    {Image: "mysql"},
+						},
+					},
+				},
+			},
+			Output: GithubWorkflow{
+				Jobs: map[string]*Job{
+					"build": {
+						Services: map[string]*Service{
+							"redis":    {Image: "redis", Ports: []interface{}{6379}},
+							"postgres": {Image: "postgres", Ports: []interface{}{5432, "5434:5432"}},
+							"mysql":    {Image: "mysql"},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, testcase := range testcases {
@@ -326,3 +780,375 @@ func TestSync_MergeWorkflow(t *testing.T) {
 		assert.EqualValues(t, testcase.Dst, testcase.Output, testcase.Description)
 	}
 }
+
+func TestSync_MergeWorkflowDeleteMarkers(t *testing.T) {
+	remote := `
+on:
+  push:
+    branches: [main, master]
+  pull_request:
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    container: node:14
+    timeout-minutes: 10
+    steps:
+      - name: checkout
+        uses: actions/checkout@v2
+      - name: legacy
+        run: make legacy
+  legacy:
+    runs-on: ubuntu-latest
+`
+	local := `
+on:
+  push:
+    branches: [$delete:master]
+  pull_request: $delete
+jobs:
+  build:
+    container: $delete
+    steps:
+      - name: legacy
+        $delete: true
+  legacy: $delete
+`
+	expected := `
+on:
+  push:
+    branches: [main]
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    timeout-minutes: 10
+    steps:
+      - name: checkout
+        uses: actions/checkout@v2
+`
+
+	dst := GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal([]byte(remote), &dst))
+	src := GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal([]byte(local), &src))
+
+	assert.Nil(t, MergeWorkflow(&dst, src))
+
+	output, err := yaml.Marshal(&dst)
+	assert.Nil(t, err)
+
+	var expectedData, actualData interface{}
+	assert.Nil(t, yaml.Unmarshal([]byte(expected), &expectedData))
+	assert.Nil(t, yaml.Unmarshal(output, &actualData))
+	assert.EqualValues(t, expectedData, actualData, string(output))
+}
+
+func TestSync_MergeWorkflowDeleteValues(t *testing.T) {
+	remote := `
+jobs:
+  build:
+    runs-on: [self-hosted, linux, x64]
+    strategy:
+      matrix:
+        os: [ubuntu-latest, windows-latest]
+        node: [12, 14, 16]
+  lint:
+    runs-on: linux
+`
+	local := `
+jobs:
+  build:
+    runs-on: [self-hosted, $delete:linux]
+    strategy:
+      matrix:
+        os: [$delete:windows-latest]
+        node: [$delete:12, 18]
+  lint:
+    runs-on: [$delete:linux, ubuntu-latest]
+  test:
+    runs-on: [ubuntu-latest, $delete:linux]
+    strategy:
+      matrix:
+        node: [16, $delete:14]
+`
+	expected := `
+jobs:
+  build:
+    runs-on: [self-hosted, x64]
+    strategy:
+      matrix:
+        os: [ubuntu-latest]
+        node: [14, 16, 18]
+  lint:
+    runs-on: [ubuntu-latest]
+  test:
+    runs-on: [ubuntu-latest]
+    strategy:
+      matrix:
+        node: [16]
+`
+
+	dst := GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal([]byte(remote), &dst))
+	src := GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal([]byte(local), &src))
+
+	assert.Nil(t, MergeWorkflow(&dst, src))
+
+	output, err := yaml.Marshal(&dst)
+	assert.Nil(t, err)
+
+	var expectedData, actualData interface{}
+	assert.Nil(t, yaml.Unmarshal([]byte(expected), &expectedData))
+	assert.Nil(t, yaml.Unmarshal(output, &actualData))
+	assert.EqualValues(t, expectedData, actualData, string(output))
+}
+
+func TestSync_MergeWorkflowPermissionsAndConcurrency(t *testing.T) {
+	remote := `
+permissions:
+  contents: read
+  issues: write
+concurrency:
+  group: ci-${{ github.ref }}
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    permissions:
+      contents: read
+      packages: read
+    concurrency: build
+  deploy:
+    runs-on: ubuntu-latest
+    permissions: read-all
+`
+	local := `
+permissions:
+  pull-requests: write
+  issues: $delete
+concurrency:
+  cancel-in-progress: true
+jobs:
+  build:
+    permissions:
+      packages: write
+    concurrency:
+      group: build
+  deploy:
+    permissions:
+      contents: read
+  lint:
+    runs-on: ubuntu-latest
+    permissions: {}
+`
+	expected := `
+permissions:
+  contents: read
+  pull-requests: write
+concurrency:
+  group: ci-${{ github.ref }}
+  cancel-in-progress: true
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    permissions:
+      contents: read
+      packages: write
+    concurrency:
+      group: build
+  deploy:
+    runs-on: ubuntu-latest
+    permissions:
+      contents: read
+  lint:
+    runs-on: ubuntu-latest
+    permissions: {}
+`
+
+	dst := GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal([]byte(remote), &dst))
+	src := GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal([]byte(local), &src))
+
+	assert.Nil(t, MergeWorkflow(&dst, src))
+
+	output, err := yaml.Marshal(&dst)
+	assert.Nil(t, err)
+
+	var expectedData, actualData interface{}
+	assert.Nil(t, yaml.Unmarshal([]byte(expected), &expectedData))
+	assert.Nil(t, yaml.Unmarshal(output, &actualData))
+	assert.EqualValues(t, expectedData, actualData, string(output))
+}
+
+func TestSync_MergeWorkflowEventOrder(t *testing.T) {
+	testcases := []struct {
+		Description string
+		Remote      string
+		Local       string
+		Output      string
+	}{
+		{
+			Description: "Keep the order of the remote shorthand",
+			Remote:      "on: [push, pull_request]\n",
+			Local:       "on: [pull_request, push]\n",
+			Output:      "\"on\":\n    - push\n    - pull_request\n",
+		},
+		{
+			Description: "Configure an event of the remote shorthand",
+			Remote:      "on: [workflow_dispatch, pull_request, push]\n",
+			Local:       "on:\n  push:\n    branches: [main]\n",
+			Output:      "\"on\":\n    workflow_dispatch:\n    pull_request:\n    push:\n        branches:\n            - main\n",
+		},
+		{
+			Description: "Append new events after the remote events",
+			Remote:      "on:\n  push:\n  pull_request:\n",
+			Local:       "on:\n  workflow_dispatch:\n  pull_request:\n",
+			Output:      "\"on\":\n    push:\n    pull_request:\n    workflow_dispatch:\n",
+		},
+	}
+
+	for _, testcase := range testcases {
+		dst := struct {
+			On On `yaml:"on"`
+		}{}
+		assert.Nil(t, yaml.Unmarshal([]byte(testcase.Remote), &dst), testcase.Description)
+		src := GithubWorkflow{}
+		assert.Nil(t, yaml.Unmarshal([]byte(testcase.Local), &src), testcase.Description)
+
+		workflow := GithubWorkflow{On: dst.On}
+		assert.Nil(t, MergeWorkflow(&workflow, src), testcase.Description)
+		dst.On = workflow.On
+
+		output, err := yaml.Marshal(&dst)
+		assert.Nil(t, err, testcase.Description)
+		assert.Equal(t, testcase.Output, string(output), testcase.Description)
+	}
+}
+
+func TestSync_MergeWorkflowStrategies(t *testing.T) {
+	remote := `
+on: push
+env:
+  TOKEN: remote
+jobs:
+  build:
+    runs-on: self-hosted
+    needs: [lint]
+    steps:
+      - name: checkout
+        uses: actions/checkout@v2
+      - name: custom
+        run: make custom
+  deploy:
+    runs-on: self-hosted
+    steps:
+      - name: deploy
+        run: make deploy
+`
+	local := `
+$merge:
+  /jobs/*/runs-on: keep-remote
+  /jobs/build/steps: replace
+  /jobs/build/needs: append
+  /env: replace
+on: push
+env:
+  CI: "true"
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    needs: [test]
+    steps:
+      - name: checkout
+        uses: actions/checkout@v3
+  release:
+    runs-on: ubuntu-latest
+`
+	expected := `
+on: push
+env:
+  CI: "true"
+jobs:
+  build:
+    runs-on: self-hosted
+    needs: [lint, test]
+    steps:
+      - name: checkout
+        uses: actions/checkout@v3
+  deploy:
+    runs-on: self-hosted
+    steps:
+      - name: deploy
+        run: make deploy
+  release:
+    runs-on: ubuntu-latest
+`
+
+	dst := GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal([]byte(remote), &dst))
+	src := GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal([]byte(local), &src))
+
+	assert.Nil(t, MergeWorkflow(&dst, src))
+	assert.Nil(t, dst.Merge)
+
+	output, err := yaml.Marshal(&dst)
+	assert.Nil(t, err)
+
+	var expectedData, actualData interface{}
+	assert.Nil(t, yaml.Unmarshal([]byte(expected), &expectedData))
+	assert.Nil(t, yaml.Unmarshal(output, &actualData))
+	assert.EqualValues(t, expectedData, actualData, string(output))
+}
+
+func TestSync_MergeWorkflowStrategiesPreserveFormat(t *testing.T) {
+	remote := `# CI of the service
+on: push
+jobs:
+  build:
+    # pinned runner
+    runs-on: self-hosted # do not change
+    timeout-minutes: ${{ matrix.timeout }}
+    continue-on-error: true
+    steps:
+      - name: checkout
+        uses: actions/checkout@v2
+      - name: custom
+        run: make custom # legacy
+`
+	local := `
+$merge:
+  /jobs/*/runs-on: keep-remote
+  /jobs/build/steps: replace
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - name: checkout
+        uses: actions/checkout@v3
+`
+	expected := `# CI of the service
+on: push
+jobs:
+  build:
+    # pinned runner
+    runs-on: self-hosted # do not change
+    timeout-minutes: ${{ matrix.timeout }}
+    continue-on-error: true
+    steps:
+      - name: checkout
+        uses: actions/checkout@v3
+`
+
+	dst := GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal([]byte(remote), &dst))
+	src := GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal([]byte(local), &src))
+
+	assert.Nil(t, MergeWorkflow(&dst, src))
+
+	output, err := common.MarshalWithFormat([]byte(remote), &dst)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, string(output))
+}
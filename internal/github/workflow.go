@@ -1,53 +1,116 @@
 package github
 
+import (
+	"ghconfig/internal/common"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
 // https://docs.github.com/en/actions/reference/workflow-syntax-for-github-actions
 
 type (
-	JobEnv           = map[string]string
-	ContainerEnv     = map[string]string
-	ContainerVolumes = map[string]string
-	ServiceEnv       = map[string]string
-	ServiceVolumes   = map[string]string
+	ContainerEnv     = map[string]interface{}
+	ContainerVolumes = []string
 	Outputs          = map[string]string
-	With             = map[string]string
-	Env              = map[string]string
+	With             = map[string]interface{}
+	Env              = map[string]interface{}
 	MatrixValue      = interface{}
 	Services         = map[string]*Service
 
 	Jobs = map[string]*Job
 
-	Matrix         = map[string]MatrixValue
+	Matrix = map[string]MatrixValue
+
+	// Extra holds keys which are not part of the model so that they survive a round-trip.
+	Extra = map[string]interface{}
+
 	GithubWorkflow struct {
-		Name     string   `yaml:"name,omitempty" json:"name,omitempty"`
-		On       On       `yaml:"on,omitempty" json:"on,omitempty"`
-		Env      Env      `yaml:"env,omitempty" json:"env,omitempty"`
-		Defaults Defaults `yaml:"defaults,omitempty" json:"defaults,omitempty"`
-		Jobs     Jobs     `yaml:"jobs,omitempty" json:"jobs,omitempty"`
+		Name        string      `yaml:"name,omitempty" json:"name,omitempty"`
+		RunName     string      `yaml:"run-name,omitempty" json:"run-name,omitempty"`
+		On          On          `yaml:"on,omitempty" json:"on,omitempty"`
+		Permissions interface{} `yaml:"permissions,omitempty" json:"permissions,omitempty"` // string or map
+		Env         Env         `yaml:"env,omitempty" json:"env,omitempty"`
+		Defaults    Defaults    `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+		Concurrency interface{} `yaml:"concurrency,omitempty" json:"concurrency,omitempty"` // string or map
+		Jobs        Jobs        `yaml:"jobs,omitempty" json:"jobs,omitempty"`
+		Extra       Extra       `yaml:",inline" json:"-"`
+
+		// Merge selects the merge strategy of paths in the local template e.g `/jobs/build/steps: replace`
+		Merge common.MergeStrategies `yaml:"$merge,omitempty" json:"-"`
 	}
 	Schedule struct {
 		Cron string `yaml:"cron,omitempty" json:"cron,omitempty"`
 	}
-	Push struct {
-		PathsIgnore []string `yaml:"paths-ignore,omitempty" json:"paths-ignore,omitempty"`
-		TagsIgnore  []string `yaml:"tags-ignore,omitempty" json:"tags-ignore,omitempty"`
-		Branches    []string `yaml:"branches,omitempty" json:"branches,omitempty"`
-		Tags        []string `yaml:"tags,omitempty" json:"tags,omitempty"`
-	}
-	PullRequest struct {
-		PathsIgnore []string `yaml:"paths-ignore,omitempty" json:"paths-ignore,omitempty"`
-		TagsIgnore  []string `yaml:"tags-ignore,omitempty" json:"tags-ignore,omitempty"`
-		Branches    []string `yaml:"branches,omitempty" json:"branches,omitempty"`
-		Tags        []string `yaml:"tags,omitempty" json:"tags,omitempty"`
-	}
-	Release struct {
-		Types []string `yaml:"types" json:"types"`
+	// Event describes the activity types, filters and inputs of a workflow trigger. Activity types
+	// and filters can be a single string e.g `branches: main`.
+	Event struct {
+		Types          StringArray            `yaml:"types,omitempty" json:"types,omitempty"`
+		Branches       StringArray            `yaml:"branches,omitempty" json:"branches,omitempty"`
+		BranchesIgnore StringArray            `yaml:"branches-ignore,omitempty" json:"branches-ignore,omitempty"`
+		Tags           StringArray            `yaml:"tags,omitempty" json:"tags,omitempty"`
+		TagsIgnore     StringArray            `yaml:"tags-ignore,omitempty" json:"tags-ignore,omitempty"`
+		Paths          StringArray            `yaml:"paths,omitempty" json:"paths,omitempty"`
+		PathsIgnore    StringArray            `yaml:"paths-ignore,omitempty" json:"paths-ignore,omitempty"`
+		Workflows      StringArray            `yaml:"workflows,omitempty" json:"workflows,omitempty"`
+		Inputs         map[string]interface{} `yaml:"inputs,omitempty" json:"inputs,omitempty"`
+		Outputs        map[string]interface{} `yaml:"outputs,omitempty" json:"outputs,omitempty"`
+		Secrets        map[string]interface{} `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+		Extra          Extra                  `yaml:",inline" json:"-"`
+
+		// Delete removes the event from the remote file e.g `push: $delete`
+		Delete bool `yaml:"$delete,omitempty" json:"-"`
 	}
+	Push        = Event
+	PullRequest = Event
+	Release     = Event
+
+	// On describes the events which trigger a workflow. An event without configuration
+	// e.g `on: [push]` is represented by an empty Event.
 	On struct {
-		Schedule    []Schedule  `yaml:"schedule,omitempty" json:"schedule,omitempty"`
-		Push        Push        `yaml:"push,omitempty" json:"push,omitempty"`
-		Release     Release     `yaml:"release,omitempty" json:"release,omitempty"`
-		PageBuild   string      `yaml:"page_build,omitempty" json:"page_build,omitempty"`
-		PullRequest PullRequest `yaml:"pull_request,omitempty" json:"pull_request,omitempty"`
+		BranchProtectionRule     *Event     `yaml:"branch_protection_rule,omitempty" json:"branch_protection_rule,omitempty"`
+		CheckRun                 *Event     `yaml:"check_run,omitempty" json:"check_run,omitempty"`
+		CheckSuite               *Event     `yaml:"check_suite,omitempty" json:"check_suite,omitempty"`
+		Create                   *Event     `yaml:"create,omitempty" json:"create,omitempty"`
+		Delete                   *Event     `yaml:"delete,omitempty" json:"delete,omitempty"`
+		Deployment               *Event     `yaml:"deployment,omitempty" json:"deployment,omitempty"`
+		DeploymentStatus         *Event     `yaml:"deployment_status,omitempty" json:"deployment_status,omitempty"`
+		Discussion               *Event     `yaml:"discussion,omitempty" json:"discussion,omitempty"`
+		DiscussionComment        *Event     `yaml:"discussion_comment,omitempty" json:"discussion_comment,omitempty"`
+		Fork                     *Event     `yaml:"fork,omitempty" json:"fork,omitempty"`
+		Gollum                   *Event     `yaml:"gollum,omitempty" json:"gollum,omitempty"`
+		IssueComment             *Event     `yaml:"issue_comment,omitempty" json:"issue_comment,omitempty"`
+		Issues                   *Event     `yaml:"issues,omitempty" json:"issues,omitempty"`
+		Label                    *Event     `yaml:"label,omitempty" json:"label,omitempty"`
+		MergeGroup               *Event     `yaml:"merge_group,omitempty" json:"merge_group,omitempty"`
+		Milestone                *Event     `yaml:"milestone,omitempty" json:"milestone,omitempty"`
+		PageBuild                *Event     `yaml:"page_build,omitempty" json:"page_build,omitempty"`
+		Project                  *Event     `yaml:"project,omitempty" json:"project,omitempty"`
+		ProjectCard              *Event     `yaml:"project_card,omitempty" json:"project_card,omitempty"`
+		ProjectColumn            *Event     `yaml:"project_column,omitempty" json:"project_column,omitempty"`
+		Public                   *Event     `yaml:"public,omitempty" json:"public,omitempty"`
+		PullRequest              *Event     `yaml:"pull_request,omitempty" json:"pull_request,omitempty"`
+		PullRequestReview        *Event     `yaml:"pull_request_review,omitempty" json:"pull_request_review,omitempty"`
+		PullRequestReviewComment *Event     `yaml:"pull_request_review_comment,omitempty" json:"pull_request_review_comment,omitempty"`
+		PullRequestTarget        *Event     `yaml:"pull_request_target,omitempty" json:"pull_request_target,omitempty"`
+		Push                     *Event     `yaml:"push,omitempty" json:"push,omitempty"`
+		RegistryPackage          *Event     `yaml:"registry_package,omitempty" json:"registry_package,omitempty"`
+		Release                  *Event     `yaml:"release,omitempty" json:"release,omitempty"`
+		RepositoryDispatch       *Event     `yaml:"repository_dispatch,omitempty" json:"repository_dispatch,omitempty"`
+		Schedule                 []Schedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+		Status                   *Event     `yaml:"status,omitempty" json:"status,omitempty"`
+		Watch                    *Event     `yaml:"watch,omitempty" json:"watch,omitempty"`
+		WorkflowCall             *Event     `yaml:"workflow_call,omitempty" json:"workflow_call,omitempty"`
+		WorkflowDispatch         *Event     `yaml:"workflow_dispatch,omitempty" json:"workflow_dispatch,omitempty"`
+		WorkflowRun              *Event     `yaml:"workflow_run,omitempty" json:"workflow_run,omitempty"`
+		Extra                    Extra      `yaml:",inline" json:"-"`
+
+		// shorthand is set when the events were declared as a string or list e.g `on: [push]`
+		shorthand bool
+		// order holds the names of the events in the order of the file
+		order []string
 	}
 
 	Run struct {
@@ -59,77 +122,288 @@ type (
 	}
 
 	Strategy struct {
-		Matrix      Matrix `yaml:"matrix,omitempty" json:"matrix,omitempty"`
-		MaxParallel int    `yaml:"max-parallel,omitempty" json:"max-parallel,omitempty"`
-		FailFast    bool   `yaml:"fail-fast,omitempty" json:"fail-fast,omitempty"`
+		Matrix      interface{} `yaml:"matrix,omitempty" json:"matrix,omitempty"`             // Matrix or expression
+		MaxParallel interface{} `yaml:"max-parallel,omitempty" json:"max-parallel,omitempty"` // number or expression
+		FailFast    interface{} `yaml:"fail-fast,omitempty" json:"fail-fast,omitempty"`       // bool or expression, defaults to true on github
 	}
 
 	Step struct {
-		Uses            string `yaml:"uses,omitempty" json:"uses,omitempty"`
-		ID              string `yaml:"id,omitempty" json:"id,omitempty"`
-		If              string `yaml:"if,omitempty" json:"if,omitempty"`
-		Name            string `yaml:"name,omitempty" json:"name,omitempty"`
-		With            With   `yaml:"with,omitempty" json:"with,omitempty"`
-		Run             string `yaml:"run,omitempty" json:"run,omitempty"`
-		ContinueOnError bool   `yaml:"continue-on-error,omitempty" json:"continue-on-error,omitempty"`
-		TimeoutMinutes  int    `yaml:"timeout-minutes,omitempty" json:"timeout-minutes,omitempty"`
+		Uses             string      `yaml:"uses,omitempty" json:"uses,omitempty"`
+		ID               string      `yaml:"id,omitempty" json:"id,omitempty"`
+		If               string      `yaml:"if,omitempty" json:"if,omitempty"`
+		Name             string      `yaml:"name,omitempty" json:"name,omitempty"`
+		With             With        `yaml:"with,omitempty" json:"with,omitempty"`
+		Env              interface{} `yaml:"env,omitempty" json:"env,omitempty"` // map or expression
+		Run              string      `yaml:"run,omitempty" json:"run,omitempty"`
+		Shell            string      `yaml:"shell,omitempty" json:"shell,omitempty"`
+		WorkingDirectory string      `yaml:"working-directory,omitempty" json:"working-directory,omitempty"`
+		ContinueOnError  interface{} `yaml:"continue-on-error,omitempty" json:"continue-on-error,omitempty"` // bool or expression
+		TimeoutMinutes   interface{} `yaml:"timeout-minutes,omitempty" json:"timeout-minutes,omitempty"`     // number or expression
+		Extra            Extra       `yaml:",inline" json:"-"`
+
+		// Delete removes the step with the same id, name or action from the remote file
+		Delete bool `yaml:"$delete,omitempty" json:"-"`
+		// Before and After insert a new step next to the step with the given id, name or action
+		Before string `yaml:"$before,omitempty" json:"-"`
+		After  string `yaml:"$after,omitempty" json:"-"`
 	}
-	Container struct {
-		Image   string         `yaml:"image,omitempty" json:"image,omitempty"`
-		Env     ContainerEnv   `yaml:"env,omitempty" json:"env,omitempty"`
-		Ports   []string       `yaml:"ports,omitempty" json:"ports,omitempty"`
-		Volumes ServiceVolumes `yaml:"volumes,omitempty" json:"volumes,omitempty"`
-		Options string         `yaml:"options,omitempty" json:"options,omitempty"`
+	Credentials struct {
+		Username string `yaml:"username,omitempty" json:"username,omitempty"`
+		Password string `yaml:"password,omitempty" json:"password,omitempty"`
+		Extra    Extra  `yaml:",inline" json:"-"`
 	}
+	Container struct {
+		Image       string           `yaml:"image,omitempty" json:"image,omitempty"`
+		Credentials *Credentials     `yaml:"credentials,omitempty" json:"credentials,omitempty"`
+		Env         ContainerEnv     `yaml:"env,omitempty" json:"env,omitempty"`
+		Ports       []interface{}    `yaml:"ports,omitempty" json:"ports,omitempty"` // numbers or strings e.g `8080:80`
+		Volumes     ContainerVolumes `yaml:"volumes,omitempty" json:"volumes,omitempty"`
+		Options     string           `yaml:"options,omitempty" json:"options,omitempty"`
+		Extra       Extra            `yaml:",inline" json:"-"`
 
-	ContainerMarshaler struct {
-		Image string `yaml:"image,omitempty" json:"image,omitempty"`
-	}
+		// Delete removes the service from the remote file e.g `redis: $delete`
+		Delete bool `yaml:"$delete,omitempty" json:"-"`
 
-	Service struct {
-		Image   string           `yaml:"image,omitempty" json:"image,omitempty"`
-		Env     ServiceEnv       `yaml:"env,omitempty" json:"env,omitempty"`
-		Ports   []string         `yaml:"ports,omitempty" json:"ports,omitempty"`
-		Volumes ContainerVolumes `yaml:"volumes,omitempty" json:"volumes,omitempty"`
-		Options string           `yaml:"options,omitempty" json:"options,omitempty"`
+		// shorthand is set when only the image name was passed e.g `container: node:14`
+		shorthand bool
 	}
+	// Service is a container which hosts a service for a job e.g a database
+	Service = Container
 
 	Job struct {
-		RunsOn          string      `yaml:"runs-on,omitempty" json:"runs-on,omitempty"`
-		Strategy        Strategy    `yaml:"strategy,omitempty" json:"strategy,omitempty"`
-		Name            string      `yaml:"name,omitempty" json:"name,omitempty"`
-		Env             JobEnv      `yaml:"env,omitempty" json:"env,omitempty"`
-		ContinueOnError bool        `yaml:"continue-on-error,omitempty" json:"continue-on-error,omitempty"`
-		If              string      `yaml:"if,omitempty" json:"if,omitempty"`
-		Defaults        Defaults    `yaml:"defaults,omitempty" json:"defaults,omitempty"`
-		Outputs         Outputs     `yaml:"outputs,omitempty" json:"outputs,omitempty"`
-		Steps           []*Step     `yaml:"steps,omitempty" json:"steps,omitempty"`
-		Needs           StringArray `yaml:"needs,omitempty" json:"needs,omitempty"`         // string or array
-		Container       Container   `yaml:"container,omitempty" json:"container,omitempty"` // can be string if only an image name is passed
-		Services        Services    `yaml:"services,omitempty" json:"services,omitempty"`
+		RunsOn          interface{}            `yaml:"runs-on,omitempty" json:"runs-on,omitempty"` // string, array or map (group, labels)
+		Strategy        Strategy               `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+		Name            string                 `yaml:"name,omitempty" json:"name,omitempty"`
+		Permissions     interface{}            `yaml:"permissions,omitempty" json:"permissions,omitempty"`             // string or map
+		Environment     interface{}            `yaml:"environment,omitempty" json:"environment,omitempty"`             // string or map (name, url)
+		Concurrency     interface{}            `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`             // string or map (group, cancel-in-progress)
+		Env             interface{}            `yaml:"env,omitempty" json:"env,omitempty"`                             // map or expression
+		ContinueOnError interface{}            `yaml:"continue-on-error,omitempty" json:"continue-on-error,omitempty"` // bool or expression
+		TimeoutMinutes  interface{}            `yaml:"timeout-minutes,omitempty" json:"timeout-minutes,omitempty"`     // number or expression
+		If              string                 `yaml:"if,omitempty" json:"if,omitempty"`
+		Defaults        Defaults               `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+		Outputs         Outputs                `yaml:"outputs,omitempty" json:"outputs,omitempty"`
+		Steps           []*Step                `yaml:"steps,omitempty" json:"steps,omitempty"`
+		Needs           StringArray            `yaml:"needs,omitempty" json:"needs,omitempty"`         // string or array
+		Container       Container              `yaml:"container,omitempty" json:"container,omitempty"` // can be string if only an image name is passed
+		Services        Services               `yaml:"services,omitempty" json:"services,omitempty"`
+		Uses            string                 `yaml:"uses,omitempty" json:"uses,omitempty"` // reusable workflow
+		With            map[string]interface{} `yaml:"with,omitempty" json:"with,omitempty"`
+		Secrets         interface{}            `yaml:"secrets,omitempty" json:"secrets,omitempty"` // "inherit" or map
+		Extra           Extra                  `yaml:",inline" json:"-"`
+
+		// Delete removes the job from the remote file e.g `legacy: $delete`
+		Delete bool `yaml:"$delete,omitempty" json:"-"`
 	}
 
 	StringArray []string
 )
 
-func (c *Container) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	var container ContainerMarshaler
-	err := unmarshal(&container)
+func (o *On) UnmarshalYAML(value *yaml.Node) error {
+	type events On
+
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	order := []string{}
+	switch value.Kind {
+	case yaml.ScalarNode:
+		node.Content = append(node.Content, eventNode(value.Value)...)
+		order = append(order, value.Value)
+	case yaml.SequenceNode:
+		for _, item := range value.Content {
+			node.Content = append(node.Content, eventNode(item.Value)...)
+			order = append(order, item.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(value.Content)-1; i += 2 {
+			key, val := value.Content[i], value.Content[i+1]
+			order = append(order, key.Value)
+			// `push:` without configuration is decoded as an empty event
+			if val.Kind == yaml.ScalarNode && val.Tag == "!!null" {
+				val = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			} else if isDeleteMarker(val) {
+				val = deleteNode()
+			}
+			node.Content = append(node.Content, key, val)
+		}
+	default:
+		return value.Decode((*events)(o))
+	}
+
+	e := events{}
+	err := node.Decode(&e)
 	if err != nil {
-		var imageName string
-		err := unmarshal(&imageName)
-		if err != nil {
-			return err
+		return err
+	}
+	*o = On(e)
+	o.shorthand = value.Kind != yaml.MappingNode
+	o.order = order
+	return nil
+}
+
+// MarshalYAML encodes the events in the order of the decoded file, new events follow in the order of
+// the struct.
+func (o On) MarshalYAML() (interface{}, error) {
+	type events On
+
+	if o.shorthand {
+		if names, ok := o.eventNames(); ok {
+			sort.SliceStable(names, func(i, j int) bool {
+				return o.position(names[i]) < o.position(names[j])
+			})
+			if len(names) == 1 {
+				return names[0], nil
+			}
+			return names, nil
 		}
-		*c = Container{Image: imageName}
-	} else {
-		*c = Container{
-			Image: container.Image,
+	}
+
+	node := &yaml.Node{}
+	err := node.Encode(events(o))
+	if err != nil {
+		return nil, err
+	}
+	pairs := [][]*yaml.Node{}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		value := node.Content[i+1]
+		// an event without configuration is encoded as `push:` instead of `push: null`
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			value.Value = ""
+		}
+		pairs = append(pairs, node.Content[i:i+2])
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return o.position(pairs[i][0].Value) < o.position(pairs[j][0].Value)
+	})
+	node.Content = []*yaml.Node{}
+	for _, pair := range pairs {
+		node.Content = append(node.Content, pair...)
+	}
+	return node, nil
+}
+
+// position returns the index of the event in the decoded file. Unknown events are sorted last.
+func (o On) position(name string) int {
+	for i, event := range o.order {
+		if event == name {
+			return i
 		}
 	}
+	return len(o.order)
+}
+
+// eventNames returns the names of all events. It returns false when any event is configured
+// and can't be represented in the short form e.g `on: [push, pull_request]`.
+func (o On) eventNames() ([]string, bool) {
+	if len(o.Schedule) > 0 || len(o.Extra) > 0 {
+		return nil, false
+	}
+	names := []string{}
+	v := reflect.ValueOf(o)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Type() != eventType || v.Field(i).IsNil() {
+			continue
+		}
+		if event := v.Field(i).Interface().(*Event); !event.isEmpty() {
+			return nil, false
+		}
+		names = append(names, strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0])
+	}
+	return names, true
+}
+
+func (e Event) MarshalYAML() (interface{}, error) {
+	type event Event
+
+	// an event without configuration is encoded as `push:`
+	if e.isEmpty() {
+		return nil, nil
+	}
+	return event(e), nil
+}
+
+func (e Event) isEmpty() bool {
+	v := reflect.ValueOf(e)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Kind() == reflect.Bool {
+			if v.Field(i).Bool() {
+				return false
+			}
+			continue
+		}
+		if v.Field(i).Len() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+var eventType = reflect.TypeOf(&Event{})
+
+func eventNode(name string) []*yaml.Node {
+	return []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: name},
+		{Kind: yaml.MappingNode, Tag: "!!map"},
+	}
+}
+
+func (j *Job) UnmarshalYAML(value *yaml.Node) error {
+	type job Job
+
+	if isDeleteMarker(value) {
+		*j = Job{Delete: true}
+		return nil
+	}
+
+	jo := job{}
+	err := value.Decode(&jo)
+	if err != nil {
+		return err
+	}
+	*j = Job(jo)
+	return nil
+}
+
+func (c *Container) UnmarshalYAML(value *yaml.Node) error {
+	type container Container
+
+	if isDeleteMarker(value) {
+		*c = Container{Delete: true}
+		return nil
+	}
+	if value.Kind == yaml.ScalarNode {
+		*c = Container{Image: value.Value, shorthand: true}
+		return nil
+	}
+
+	con := container{}
+	err := value.Decode(&con)
+	if err != nil {
+		return err
+	}
+	*c = Container(con)
 	return nil
 }
 
+func (c Container) MarshalYAML() (interface{}, error) {
+	type container Container
+
+	if c.shorthand && reflect.DeepEqual(c, Container{Image: c.Image, shorthand: true}) {
+		return c.Image, nil
+	}
+	return container(c), nil
+}
+
+func isDeleteMarker(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Value == common.DeleteMarker
+}
+
+func deleteNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: common.DeleteMarker},
+		{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"},
+	}}
+}
+
 func (a *StringArray) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var multi []string
 	err := unmarshal(&multi)
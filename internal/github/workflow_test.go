@@ -0,0 +1,319 @@
+package github
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type roundTripTestCase struct {
+	Description string
+	Input       string
+}
+
+func TestWorkflow_RoundTrip(t *testing.T) {
+	testcases := []roundTripTestCase{
+		{
+			Description: "Events as string",
+			Input:       "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n",
+		},
+		{
+			Description: "Events as list",
+			Input:       "on: [push, workflow_dispatch]\njobs:\n  build:\n    runs-on: ubuntu-latest\n",
+		},
+		{
+			Description: "Events without configuration",
+			Input: `
+on:
+  push:
+  workflow_dispatch:
+  merge_group:
+jobs:
+  build:
+    runs-on: ubuntu-latest
+`,
+		},
+		{
+			Description: "Full workflow syntax",
+			Input: `
+name: CI
+run-name: Deploy by ${{ github.actor }}
+on:
+  push:
+    branches: [main]
+    paths: ["src/**"]
+  pull_request_target:
+    types: [opened]
+  workflow_dispatch:
+    inputs:
+      level:
+        type: choice
+        options: [info, debug]
+        default: info
+  workflow_call:
+    inputs:
+      version:
+        type: string
+        required: true
+    secrets:
+      token:
+        required: true
+  workflow_run:
+    workflows: [Build]
+    types: [completed]
+  schedule:
+    - cron: "0 0 * * *"
+permissions:
+  contents: read
+concurrency:
+  group: ${{ github.ref }}
+  cancel-in-progress: true
+defaults:
+  run:
+    shell: bash
+jobs:
+  build:
+    runs-on: [self-hosted, linux]
+    permissions: read-all
+    environment:
+      name: production
+      url: https://example.com
+    concurrency: build
+    timeout-minutes: 30
+    strategy:
+      fail-fast: false
+      matrix:
+        os: [ubuntu-latest]
+    steps:
+      - uses: actions/checkout@v2
+      - name: test
+        run: make test
+        shell: bash
+        working-directory: ./src
+        env:
+          CI: "true"
+  call:
+    uses: org/repo/.github/workflows/build.yml@main
+    with:
+      version: 1
+    secrets: inherit
+`,
+		},
+		{
+			Description: "Unknown keys are preserved",
+			Input: `
+on:
+  push:
+    unknown-filter: [a]
+  future_event:
+    types: [created]
+unknown-key: value
+jobs:
+  build:
+    runs-on:
+      group: large
+    unknown-job-key: 1
+    steps:
+      - run: echo
+        unknown-step-key: true
+`,
+		},
+		{
+			Description: "Container as string",
+			Input:       "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    container: node:14\n",
+		},
+		{
+			Description: "Container and services as mapping",
+			Input: `
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    container:
+      image: ghcr.io/owner/image
+      credentials:
+        username: ${{ github.actor }}
+        password: ${{ secrets.GITHUB_TOKEN }}
+      env:
+        NODE_ENV: development
+      ports: ["80"]
+      volumes:
+        - my_docker_volume:/volume_mount
+        - /data/my_data
+      options: --cpus 1
+    services:
+      redis:
+        image: redis
+        ports: ["6379:6379"]
+        options: --health-cmd "redis-cli ping"
+      postgres:
+        image: postgres
+        credentials:
+          username: user
+          password: secret
+        env:
+          POSTGRES_PASSWORD: postgres
+        volumes:
+          - /source/directory:/destination/directory
+`,
+		},
+		{
+			Description: "Numbers in ports, container env and step inputs",
+			Input: `
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    container:
+      image: node:14
+      env:
+        PORT: 3000
+      ports: [80, "8080:80"]
+    services:
+      postgres:
+        image: postgres
+        ports: [5432]
+    steps:
+      - uses: actions/checkout@v3
+        with:
+          fetch-depth: 0
+          submodules: true
+`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		workflow := GithubWorkflow{}
+		err := yaml.Unmarshal([]byte(testcase.Input), &workflow)
+		assert.Nil(t, err, testcase.Description)
+
+		output, err := yaml.Marshal(&workflow)
+		assert.Nil(t, err, testcase.Description)
+
+		var expected, actual interface{}
+		assert.Nil(t, yaml.Unmarshal([]byte(testcase.Input), &expected), testcase.Description)
+		assert.Nil(t, yaml.Unmarshal(output, &actual), testcase.Description)
+		assert.EqualValues(t, expected, actual, testcase.Description)
+	}
+}
+
+func TestWorkflow_EventOrder(t *testing.T) {
+	testcases := []roundTripTestCase{
+		{
+			Description: "Events as list",
+			Input:       "on:\n    - pull_request\n    - push\n",
+		},
+		{
+			Description: "Events without configuration",
+			Input:       "on:\n    workflow_dispatch:\n    push:\n",
+		},
+		{
+			Description: "Events with configuration",
+			Input:       "on:\n    workflow_dispatch:\n    pull_request:\n        branches:\n            - main\n    push:\n        branches:\n            - main\n",
+		},
+	}
+
+	for _, testcase := range testcases {
+		on := struct {
+			On On `yaml:"on"`
+		}{}
+		assert.Nil(t, yaml.Unmarshal([]byte(testcase.Input), &on), testcase.Description)
+		output, err := yaml.Marshal(&on)
+		assert.Nil(t, err, testcase.Description)
+		assert.Equal(t, strings.Replace(testcase.Input, "on:", `"on":`, 1), string(output), testcase.Description)
+	}
+}
+
+func TestWorkflow_ExpressionsAndShortForms(t *testing.T) {
+	input := `
+on:
+  push:
+    branches: main
+    tags: v*
+  pull_request:
+    types: opened
+jobs:
+  build:
+    name: Build
+    runs-on: ubuntu-latest
+    env: ${{ fromJson(needs.setup.outputs.env) }}
+    continue-on-error: ${{ matrix.experimental }}
+    timeout-minutes: ${{ inputs.timeout }}
+    strategy:
+      fail-fast: ${{ inputs.fail-fast }}
+      max-parallel: ${{ inputs.parallel }}
+      matrix:
+        experimental: [true, false]
+    steps:
+      - run: make test
+        env: ${{ matrix.env }}
+        continue-on-error: true
+        timeout-minutes: 10
+`
+	workflow := GithubWorkflow{}
+	err := yaml.Unmarshal([]byte(input), &workflow)
+	assert.Nil(t, err)
+
+	assert.EqualValues(t, StringArray{"main"}, workflow.On.Push.Branches)
+	assert.EqualValues(t, StringArray{"v*"}, workflow.On.Push.Tags)
+	assert.EqualValues(t, StringArray{"opened"}, workflow.On.PullRequest.Types)
+
+	job := workflow.Jobs["build"]
+	assert.Equal(t, "${{ fromJson(needs.setup.outputs.env) }}", job.Env)
+	assert.Equal(t, "${{ matrix.experimental }}", job.ContinueOnError)
+	assert.Equal(t, "${{ inputs.timeout }}", job.TimeoutMinutes)
+	assert.Equal(t, "${{ inputs.fail-fast }}", job.Strategy.FailFast)
+	assert.Equal(t, "${{ inputs.parallel }}", job.Strategy.MaxParallel)
+	assert.Equal(t, "${{ matrix.env }}", job.Steps[0].Env)
+	assert.Equal(t, true, job.Steps[0].ContinueOnError)
+	assert.Equal(t, 10, job.Steps[0].TimeoutMinutes)
+
+	output, err := yaml.Marshal(&workflow)
+	assert.Nil(t, err)
+	actual := GithubWorkflow{}
+	assert.Nil(t, yaml.Unmarshal(output, &actual))
+	assert.EqualValues(t, workflow, actual)
+}
+
+func TestWorkflow_ValidateSchema(t *testing.T) {
+	testcases := []struct {
+		Description string
+		Input       string
+		Valid       bool
+	}{
+		{
+			Description: "Reusable workflow job without runs-on",
+			Input:       "on: workflow_call\njobs:\n  call:\n    uses: org/repo/.github/workflows/build.yml@main\n    secrets: inherit\n",
+			Valid:       true,
+		},
+		{
+			Description: "Permissions, concurrency and environment",
+			Input:       "on: [push]\npermissions: read-all\nconcurrency: ci\njobs:\n  build:\n    runs-on: ubuntu-22.04\n    environment: production\n    permissions:\n      contents: write\n    steps:\n      - run: echo\n",
+			Valid:       true,
+		},
+		{
+			Description: "Container as string and services with credentials",
+			Input:       "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    container: node:14\n    services:\n      db:\n        image: postgres\n        credentials:\n          username: user\n          password: secret\n        volumes: [/data:/data:ro]\n",
+			Valid:       true,
+		},
+		{
+			Description: "Job without runs-on and uses",
+			Input:       "on: push\njobs:\n  build:\n    steps:\n      - run: echo\n",
+			Valid:       false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		var doc interface{}
+		assert.Nil(t, yaml.Unmarshal([]byte(testcase.Input), &doc), testcase.Description)
+		data, err := json.Marshal(doc)
+		assert.Nil(t, err, testcase.Description)
+
+		result, err := ValidateSchema(string(data))
+		assert.Nil(t, err, testcase.Description)
+		assert.Equal(t, testcase.Valid, result.Valid(), testcase.Description, result.Errors())
+	}
+}
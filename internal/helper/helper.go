@@ -2,27 +2,34 @@ package helper
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"ghconfig/internal/common"
 	"ghconfig/internal/config"
 	"ghconfig/internal/dependabot"
 	gh "ghconfig/internal/github"
 	"html/template"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/Masterminds/sprig"
 	"github.com/apex/log"
 	"github.com/google/go-github/v32/github"
 	"github.com/pieterclaerhout/go-waitgroup"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/xeipuuv/gojsonschema"
 	"gopkg.in/yaml.v3"
 )
 
 func FindDependabot(dirPath string) (*config.DependabotTemplate, error) {
-	tpl := &config.DependabotTemplate{}
 	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
-		return tpl, nil
+		return nil, nil
 	}
 
 	files, err := ioutil.ReadDir(dirPath)
@@ -41,31 +48,53 @@ func FindDependabot(dirPath string) (*config.DependabotTemplate, error) {
 			fallthrough
 		case "dependabot.yaml":
 			filePath := path.Join(dirPath, fileName)
-			bytes, err := ioutil.ReadFile(filePath)
+			tpl, err := ReadDependabot(filePath)
 			if err != nil {
 				log.WithError(err).Errorf("could not read dependabot file: %v", filePath)
 				continue
 			}
-			if len(bytes) == 0 {
-				log.Infof("dependabot file is empty: %v", filePath)
+			if tpl == nil {
 				continue
 			}
-			dependabot := &dependabot.GithubDependabot{}
-			err = yaml.Unmarshal(bytes, dependabot)
-			if err != nil {
-				log.WithError(err).Errorf("could not parse workflow file: %v", filePath)
-				continue
-			}
-			tpl.RepositoryPath = path.Join(config.GithubConfigBaseDir, fileName)
-			tpl.Dependabot = dependabot
-			tpl.Filename = fileName
-
 			return tpl, nil
 		}
 	}
 	return nil, nil
 }
 
+// ReadDependabot reads a dependabot template. The file is pushed as .github/dependabot.yml
+// unless its extension is .yaml. It returns nil when the file is empty.
+func ReadDependabot(filePath string) (*config.DependabotTemplate, error) {
+	bytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) == 0 {
+		log.Infof("dependabot file is empty: %v", filePath)
+		return nil, nil
+	}
+	dependabot := &dependabot.GithubDependabot{}
+	err = yaml.Unmarshal(bytes, dependabot)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse dependabot file: %v: %w", filePath, err)
+	}
+	err = dependabot.Merge.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid merge strategies in dependabot file: %v: %w", filePath, err)
+	}
+
+	fileName := "dependabot.yml"
+	if filepath.Ext(filePath) == ".yaml" {
+		fileName = "dependabot.yaml"
+	}
+
+	return &config.DependabotTemplate{
+		RepositoryPath: path.Join(config.GithubConfigBaseDir, fileName),
+		Dependabot:     dependabot,
+		Filename:       fileName,
+	}, nil
+}
+
 func FindWorkflows(dirPath string) ([]*config.WorkflowTemplate, error) {
 	templates := []*config.WorkflowTemplate{}
 	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
@@ -102,6 +131,11 @@ func FindWorkflows(dirPath string) ([]*config.WorkflowTemplate, error) {
 			log.WithError(err).Errorf("could not parse workflow file: %v", filePath)
 			continue
 		}
+		err = t.Merge.Validate()
+		if err != nil {
+			log.WithError(err).Errorf("invalid merge strategies in workflow file: %v", filePath)
+			continue
+		}
 		templates = append(templates, &config.WorkflowTemplate{
 			Workflow:       &t,
 			RepositoryPath: path.Join(config.GithubConfigBaseDir, config.GhWorkflowDir, workflowName),
@@ -152,6 +186,9 @@ func FindPatches(dirPath string) ([]*config.PatchData, error) {
 	return patches, nil
 }
 
+// CreatePR pushes all files to a branch and opens a draft PR. When ghconfig already has an open PR
+// against the base branch, its branch is reset to a new commit on top of the base branch and the PR is
+// updated instead.
 func CreatePR(opts *config.Config, intent *config.RepositoryUpdate) (string, error) {
 	// get ref to branch from
 	refs, _, err := opts.GithubClient.Git.ListMatchingRefs(
@@ -167,34 +204,78 @@ func CreatePR(opts *config.Config, intent *config.RepositoryUpdate) (string, err
 		return "", err
 	}
 
-	if len(refs) > 0 {
-		// create branch
-		_, _, err := opts.GithubClient.Git.CreateRef(
+	if len(refs) == 0 {
+		return "", fmt.Errorf("could not find a ref on the base branch")
+	}
+	baseSHA := refs[0].GetObject().GetSHA()
+
+	existingPR, err := FindPR(opts, intent.RepositoryOptions)
+	if err != nil {
+		return "", err
+	}
+
+	title, body, err := pullRequestContent(opts, intent)
+	if err != nil {
+		return "", err
+	}
+
+	if existingPR != nil {
+		intent.RepositoryOptions.Branch = existingPR.GetHead().GetRef()
+		intent.RepositoryOptions.PRBranchRef = "refs/heads/" + intent.RepositoryOptions.Branch
+
+		commit, err := CommitFiles(opts, intent.RepositoryOptions, baseSHA, intent.Files, true)
+		if err != nil {
+			return "", err
+		}
+		intent.CommitSHA = commit.GetSHA()
+
+		_, _, err = opts.GithubClient.PullRequests.Edit(
 			opts.Context,
 			intent.RepositoryOptions.Owner,
 			intent.RepositoryOptions.Repo,
-			&github.Reference{
-				// the name of the new branch
-				Ref: &intent.RepositoryOptions.PRBranchRef,
-				// branch from master
-				Object: &github.GitObject{SHA: refs[0].Object.SHA},
-			},
+			existingPR.GetNumber(),
+			&github.PullRequest{Title: &title, Body: &body},
 		)
 		if err != nil {
 			return "", err
 		}
-	} else {
-		return "", fmt.Errorf("could not find a ref on the base branch")
+
+		err = setDraft(opts, existingPR, !opts.PullRequest.ReadyForReview)
+		if err != nil {
+			return "", err
+		}
+
+		err = applyPullRequestMetadata(opts, intent, existingPR.GetNumber())
+		if err != nil {
+			return "", err
+		}
+
+		return existingPR.GetHTMLURL(), nil
 	}
 
-	err = UpdateRepositoryFiles(opts, intent.RepositoryOptions, intent.Files)
+	// create branch
+	_, _, err = opts.GithubClient.Git.CreateRef(
+		opts.Context,
+		intent.RepositoryOptions.Owner,
+		intent.RepositoryOptions.Repo,
+		&github.Reference{
+			// the name of the new branch
+			Ref: &intent.RepositoryOptions.PRBranchRef,
+			// branch from master
+			Object: &github.GitObject{SHA: &baseSHA},
+		},
+	)
 	if err != nil {
 		return "", err
 	}
 
-	draft := true
+	commit, err := UpdateRepositoryFiles(opts, intent.RepositoryOptions, intent.Files)
+	if err != nil {
+		return "", err
+	}
+	intent.CommitSHA = commit.GetSHA()
 
-	commitMsg := "Synchronize (.github) configurations by ghconfig"
+	draft := !opts.PullRequest.ReadyForReview
 
 	pr, _, err := opts.GithubClient.PullRequests.Create(
 		opts.Context,
@@ -202,7 +283,8 @@ func CreatePR(opts *config.Config, intent *config.RepositoryUpdate) (string, err
 		intent.RepositoryOptions.Repo,
 		&github.NewPullRequest{
 			Base:  &intent.RepositoryOptions.BaseRef,
-			Title: &commitMsg,
+			Title: &title,
+			Body:  &body,
 			Draft: &draft,
 			Head:  &intent.RepositoryOptions.Branch,
 		},
@@ -211,51 +293,341 @@ func CreatePR(opts *config.Config, intent *config.RepositoryUpdate) (string, err
 		return "", err
 	}
 
+	err = applyPullRequestMetadata(opts, intent, pr.GetNumber())
+	if err != nil {
+		return "", err
+	}
+
 	return pr.GetHTMLURL(), nil
 }
 
-func UpdateRepositoryFiles(opts *config.Config, updateOptions *config.RepositoryUpdateOptions, files []*config.RepositoryFileUpdate) error {
-	for _, file := range files {
-		// commit message
-		commitMsg := fmt.Sprintf("Update %v file by ghconfig", file.RepositoryUpdateOptions.DisplayName)
+// FindPR returns the open PR of ghconfig against the base branch or nil. Only PRs whose branch belongs to
+// the same group as the branch of updateOptions (see config.BranchNamePattern) are returned.
+func FindPR(opts *config.Config, updateOptions *config.RepositoryUpdateOptions) (*github.PullRequest, error) {
+	branchPrefix := path.Dir(updateOptions.Branch) + "/"
+	fullName := updateOptions.Owner + "/" + updateOptions.Repo
 
-		if opts.CommitMessage != "" {
-			commitMsg = opts.CommitMessage
+	listOpts := &github.PullRequestListOptions{
+		State:       "open",
+		Base:        updateOptions.BaseRef,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		prs, resp, err := opts.GithubClient.PullRequests.List(opts.Context, updateOptions.Owner, updateOptions.Repo, listOpts)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			head := pr.GetHead()
+			// PRs from forks can't be updated
+			if head.GetRepo() != nil && head.GetRepo().GetFullName() != fullName {
+				continue
+			}
+			ref := head.GetRef()
+			if strings.HasPrefix(ref, branchPrefix) && !strings.Contains(strings.TrimPrefix(ref, branchPrefix), "/") {
+				return pr, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
 		}
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// ClosePR closes the open PR of ghconfig and deletes its branch because the changes are no longer needed.
+// It returns the URL of the closed PR or an empty string when no PR was open.
+func ClosePR(opts *config.Config, updateOptions *config.RepositoryUpdateOptions) (string, error) {
+	pr, err := FindPR(opts, updateOptions)
+	if err != nil || pr == nil {
+		return "", err
+	}
+
+	_, _, err = opts.GithubClient.PullRequests.Edit(
+		opts.Context,
+		updateOptions.Owner,
+		updateOptions.Repo,
+		pr.GetNumber(),
+		&github.PullRequest{State: github.String("closed")},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = opts.GithubClient.Git.DeleteRef(
+		opts.Context,
+		updateOptions.Owner,
+		updateOptions.Repo,
+		"heads/"+pr.GetHead().GetRef(),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return pr.GetHTMLURL(), nil
+}
+
+// UpdateRepositoryFiles commits all files atomically as a single commit on top of the branch.
+func UpdateRepositoryFiles(opts *config.Config, updateOptions *config.RepositoryUpdateOptions, files []*config.RepositoryFileUpdate) (*github.Commit, error) {
+	ref, _, err := opts.GithubClient.Git.GetRef(
+		opts.Context,
+		updateOptions.Owner,
+		updateOptions.Repo,
+		"heads/"+updateOptions.Branch,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return CommitFiles(opts, updateOptions, ref.GetObject().GetSHA(), files, false)
+}
+
+// CommitFiles commits all files atomically as a single commit on top of the parent and moves the branch
+// to it. force resets a branch which doesn't contain the parent.
+func CommitFiles(opts *config.Config, updateOptions *config.RepositoryUpdateOptions, parentSHA string, files []*config.RepositoryFileUpdate, force bool) (*github.Commit, error) {
+	parent, _, err := opts.GithubClient.Git.GetCommit(
+		opts.Context,
+		updateOptions.Owner,
+		updateOptions.Repo,
+		parentSHA,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		rr, _, err := opts.GithubClient.Repositories.UpdateFile(
+	baseTree, _, err := opts.GithubClient.Git.GetTree(
+		opts.Context,
+		updateOptions.Owner,
+		updateOptions.Repo,
+		parent.GetTree().GetSHA(),
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		err = checkBaseBlob(opts, updateOptions, parentSHA, baseTree, file.RepositoryUpdateOptions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entries := []*github.TreeEntry{}
+	fileNames := []string{}
+	for _, file := range files {
+		blob, _, err := opts.GithubClient.Git.CreateBlob(
 			opts.Context,
 			updateOptions.Owner,
 			updateOptions.Repo,
-			file.RepositoryUpdateOptions.Path,
-			&github.RepositoryContentFileOptions{
-				Branch:  &updateOptions.Branch,
-				Message: &commitMsg,
-				Content: *file.RepositoryUpdateOptions.FileContent,
-				SHA:     &file.RepositoryUpdateOptions.SHA,
+			&github.Blob{
+				Content:  github.String(base64.StdEncoding.EncodeToString(*file.RepositoryUpdateOptions.FileContent)),
+				Encoding: github.String("base64"),
 			},
 		)
 		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &github.TreeEntry{
+			Path: github.String(file.RepositoryUpdateOptions.Path),
+			Mode: github.String("100644"),
+			Type: github.String("blob"),
+			SHA:  blob.SHA,
+		})
+		fileNames = append(fileNames, file.RepositoryUpdateOptions.DisplayName)
+	}
+
+	tree, _, err := opts.GithubClient.Git.CreateTree(
+		opts.Context,
+		updateOptions.Owner,
+		updateOptions.Repo,
+		parent.GetTree().GetSHA(),
+		entries,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// commit message
+	commitMsg := fmt.Sprintf("Update %v by ghconfig", strings.Join(fileNames, ", "))
+
+	if opts.CommitMessage != "" {
+		commitMsg = opts.CommitMessage
+	}
+
+	commit, _, err := opts.GithubClient.Git.CreateCommit(
+		opts.Context,
+		updateOptions.Owner,
+		updateOptions.Repo,
+		&github.Commit{
+			Message: &commitMsg,
+			Tree:    tree,
+			Parents: []*github.Commit{{SHA: parent.SHA}},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, err = opts.GithubClient.Git.UpdateRef(
+		opts.Context,
+		updateOptions.Owner,
+		updateOptions.Repo,
+		&github.Reference{
+			Ref:    github.String("refs/heads/" + updateOptions.Branch),
+			Object: &github.GitObject{SHA: commit.SHA},
+		},
+		force,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		file.RepositoryUpdateOptions.URL = commit.GetHTMLURL()
+	}
+
+	return commit, nil
+}
+
+// checkBaseBlob verifies that the file of the parent tree is still the file which was merged (same blob SHA).
+// A file which was created by ghconfig must not exist in the parent tree.
+func checkBaseBlob(opts *config.Config, updateOptions *config.RepositoryUpdateOptions, parentSHA string, tree *github.Tree, file *config.RepositoryFileUpdateOptions) error {
+	sha, found := "", false
+	for _, entry := range tree.Entries {
+		if entry.GetPath() == file.Path && entry.GetType() == "blob" {
+			sha, found = entry.GetSHA(), true
+			break
+		}
+	}
+	if !found && tree.GetTruncated() {
+		// the tree is too large to be listed completely
+		content, _, resp, err := opts.GithubClient.Repositories.GetContents(
+			opts.Context,
+			updateOptions.Owner,
+			updateOptions.Repo,
+			file.Path,
+			&github.RepositoryContentGetOptions{
+				Ref: parentSHA,
+			},
+		)
+		if err != nil && (resp == nil || resp.StatusCode != 404) {
 			return err
 		}
-		file.RepositoryUpdateOptions.URL = rr.GetHTMLURL()
+		sha = content.GetSHA()
+	}
+
+	if sha != file.SHA {
+		if file.SHA == "" {
+			return fmt.Errorf("%v was created on %v after it was read", file.Path, updateOptions.Branch)
+		}
+		if sha == "" {
+			return fmt.Errorf("%v was deleted on %v after it was read", file.Path, updateOptions.Branch)
+		}
+		return fmt.Errorf("%v was changed on %v after it was read (blob %v, expected %v)", file.Path, updateOptions.Branch, sha, file.SHA)
 	}
 	return nil
 }
 
+// NewGithubClient creates a client for github.com or, when apiURL is set, for a GitHub Enterprise Server.
+// The upload URL is derived from the API URL when it's empty.
+func NewGithubClient(httpClient *http.Client, apiURL, uploadURL string) (*github.Client, error) {
+	if apiURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+	if uploadURL == "" {
+		uploadURL = strings.TrimSuffix(strings.TrimSuffix(apiURL, "/"), "/api/v3")
+	}
+	return github.NewEnterpriseClient(apiURL, uploadURL, httpClient)
+}
+
+// GetRemoteFile returns a file of the repository at the base ref. The content is decoded from the
+// contents API response. Files larger than 1 MB are not inlined by the contents API and are fetched
+// with the blob API instead.
+func GetRemoteFile(opts *config.Config, repoOpts *config.RepositoryUpdateOptions, filePath string) (*config.RemoteFile, *github.Response, error) {
+	content, _, resp, err := opts.GithubClient.Repositories.GetContents(
+		opts.Context,
+		repoOpts.Owner,
+		repoOpts.Repo,
+		filePath,
+		&github.RepositoryContentGetOptions{
+			Ref: repoOpts.BaseRef,
+		},
+	)
+	if err != nil {
+		return nil, resp, err
+	}
+	if content == nil {
+		return nil, resp, fmt.Errorf("%v is not a file", filePath)
+	}
+
+	if content.GetEncoding() == "none" || (content.Content == nil && content.GetSize() > 0) {
+		data, resp, err := opts.GithubClient.Git.GetBlobRaw(opts.Context, repoOpts.Owner, repoOpts.Repo, content.GetSHA())
+		if err != nil {
+			return nil, resp, err
+		}
+		return &config.RemoteFile{Content: content, Data: data}, resp, nil
+	}
+
+	data, err := content.GetContent()
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return &config.RemoteFile{Content: content, Data: []byte(data)}, resp, nil
+}
+
 func FetchAllRepos(opts *config.Config) ([]*github.Repository, error) {
+	if opts.AppInstallation && opts.RepositoryQuery == "" {
+		return FetchInstallationRepos(opts)
+	}
+
 	me, _, err := opts.GithubClient.Users.Get(opts.Context, "")
 	if err != nil {
 		return nil, err
 	}
 
-	allRepos := []*github.Repository{}
 	query := "user:" + *me.Login
 
 	if opts.RepositoryQuery != "" {
 		query = opts.RepositoryQuery
 	}
 
+	return SearchRepos(opts, query)
+}
+
+// FetchInstallationRepos returns all repositories which are accessible by the GitHub App installation.
+func FetchInstallationRepos(opts *config.Config) ([]*github.Repository, error) {
+	allRepos := []*github.Repository{}
+	listOpts := &github.ListOptions{PerPage: 100}
+
+	for {
+		repos, resp, err := opts.GithubClient.Apps.ListRepos(opts.Context, listOpts)
+		if err != nil {
+			return nil, err
+		}
+		allRepos = append(allRepos, repos...)
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	return allRepos, nil
+}
+
+// Concurrency returns the configured number of parallel workers or the default.
+func Concurrency(opts *config.Config, defaultValue int) int {
+	if opts.Concurrency > 0 {
+		return opts.Concurrency
+	}
+	return defaultValue
+}
+
+// SearchRepos returns all repositories which match the search query.
+func SearchRepos(opts *config.Config, query string) ([]*github.Repository, error) {
+	allRepos := []*github.Repository{}
+
 	fetch := func(page int) (*github.RepositoriesSearchResult, *github.Response, error) {
 		return opts.GithubClient.Search.Repositories(
 			opts.Context,
@@ -272,16 +644,16 @@ func FetchAllRepos(opts *config.Config) ([]*github.Repository, error) {
 	}
 	allRepos = append(allRepos, searchResult.Repositories...)
 
-	wg := waitgroup.NewWaitGroup(4)
+	wg := waitgroup.NewWaitGroup(Concurrency(opts, 4))
 	var results = make(chan *[]*github.Repository, resp.LastPage)
 
 	for i := 2; i <= resp.LastPage; i++ {
 		cpage := i
 		wg.Add(func() {
-			defer wg.Done()
 			repos, _, err := fetch(cpage)
 			if err != nil {
-				fmt.Printf("error fetching repositories from github: %v", err)
+				log.WithError(err).Errorf("could not fetch page %d of repositories", cpage)
+				return
 			}
 			results <- &repos.Repositories
 		})
@@ -299,13 +671,17 @@ func FetchAllRepos(opts *config.Config) ([]*github.Repository, error) {
 }
 
 func ExecuteTemplate(name string, text string, templateVars config.TemplateVars) (*bytes.Buffer, error) {
-	t := template.Must(template.New(name).
+	t, err := template.New(name).
 		Delims("$((", "))").
 		Funcs(sprig.FuncMap()).
-		Parse(text))
+		Parse(text)
+	if err != nil {
+		log.WithError(err).Error("could not parse template")
+		return nil, err
+	}
 
 	bytesCache := new(bytes.Buffer)
-	err := t.Execute(bytesCache, templateVars)
+	err = t.Execute(bytesCache, templateVars)
 	if err != nil {
 		log.WithError(err).Error("could not execute template")
 		return nil, err
@@ -313,3 +689,190 @@ func ExecuteTemplate(name string, text string, templateVars config.TemplateVars)
 
 	return bytesCache, nil
 }
+
+// ValidateRepositoryFile validates the final file content against the JSON schema
+// of the workflow or dependabot file. The returned list is empty when the file is valid.
+func ValidateRepositoryFile(file *config.RepositoryFileUpdate) ([]*config.SchemaError, error) {
+	var data interface{}
+	err := yaml.Unmarshal(*file.RepositoryUpdateOptions.FileContent, &data)
+	if err != nil {
+		return nil, err
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *gojsonschema.Result
+	if file.Workflow != nil {
+		result, err = gh.ValidateSchema(string(jsonData))
+	} else if file.Dependabot != nil {
+		result, err = dependabot.ValidateSchema(string(jsonData))
+	} else {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	schemaErrors := []*config.SchemaError{}
+	for _, resultError := range result.Errors() {
+		schemaErrors = append(schemaErrors, &config.SchemaError{
+			Filename:    file.RepositoryUpdateOptions.Path,
+			Field:       resultError.Field(),
+			Description: resultError.Description(),
+		})
+	}
+	return schemaErrors, nil
+}
+
+// UnifiedDiff returns the unified diff between the remote and the local file content.
+// An empty remote content is handled as a new file.
+func UnifiedDiff(filePath string, remote, local []byte) (string, error) {
+	diff := difflib.UnifiedDiff{
+		A:        []string{},
+		B:        difflib.SplitLines(string(local)),
+		FromFile: "/dev/null",
+		ToFile:   "b/" + filePath,
+		Context:  3,
+	}
+	if len(remote) > 0 {
+		diff.A = difflib.SplitLines(string(remote))
+		diff.FromFile = "a/" + filePath
+	}
+	return difflib.GetUnifiedDiffString(diff)
+}
+
+// IsFileUnchanged reports whether the new file content is semantically equal to the remote file.
+// Scalars are compared by their textual value and single values are equal to lists with that value
+// so that the normalization of the encoder e.g `needs: build` to `needs: [build]` isn't a change.
+// Files which don't exist on the remote are always changed.
+func IsFileUnchanged(file *config.RepositoryFileUpdate) bool {
+	if file.RepositoryUpdateOptions.RemoteFileContent == nil {
+		return false
+	}
+	remote := *file.RepositoryUpdateOptions.RemoteFileContent
+	local := *file.RepositoryUpdateOptions.FileContent
+	if bytes.Equal(remote, local) {
+		return true
+	}
+
+	var remoteNode, localNode yaml.Node
+	if err := yaml.Unmarshal(remote, &remoteNode); err != nil {
+		return false
+	}
+	if err := yaml.Unmarshal(local, &localNode); err != nil {
+		return false
+	}
+	return common.NodesEqual(&remoteNode, &localNode)
+}
+
+// LoadManifest reads the ghconfig.yaml manifest of the root directory. It returns nil when no manifest exists.
+func LoadManifest(rootDir string) (*config.Manifest, error) {
+	filePath := path.Join(rootDir, config.ManifestFileName)
+	bytes, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &config.Manifest{}
+	err = yaml.Unmarshal(bytes, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse manifest: %v: %w", filePath, err)
+	}
+
+	for i, target := range manifest.Targets {
+		if target.Name == "" {
+			target.Name = fmt.Sprintf("target-%d", i+1)
+		}
+		if target.Selector.Pattern != "" {
+			if _, err := regexp.Compile(target.Selector.Pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern of target %v: %w", target.Name, err)
+			}
+		}
+	}
+
+	return manifest, nil
+}
+
+// MatchesSelector reports whether the repository matches all criteria of the selector.
+// The search query is not evaluated because it's used to fetch the repositories.
+func MatchesSelector(selector *config.RepositorySelector, repo *github.Repository) bool {
+	if len(selector.Repositories) > 0 && !containsFold(selector.Repositories, repo.GetFullName()) {
+		return false
+	}
+	if len(selector.Languages) > 0 && !containsFold(selector.Languages, repo.GetLanguage()) {
+		return false
+	}
+	if len(selector.Topics) > 0 {
+		found := false
+		for _, topic := range repo.Topics {
+			if containsFold(selector.Topics, topic) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if selector.Pattern != "" {
+		matched, err := regexp.MatchString(selector.Pattern, repo.GetFullName())
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchRepositoryName reports whether the full name of a repository matches any of the patterns.
+// Patterns enclosed in slashes are regular expressions e.g `/^foo\/svc-.*$/`, all other patterns are globs e.g `foo/svc-*`.
+func MatchRepositoryName(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		var matched bool
+		var err error
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			matched, err = regexp.MatchString(pattern[1:len(pattern)-1], name)
+		} else {
+			matched, err = path.Match(pattern, name)
+		}
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern %v: %w", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ReadRepositoryNames reads the full names of repositories from a file. Every line contains a
+// single name, empty lines and lines starting with # are ignored.
+func ReadRepositoryNames(filePath string) ([]string, error) {
+	bytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, line := range strings.Split(string(bytes), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, nil
+}
@@ -0,0 +1,220 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"ghconfig/internal/config"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig"
+	"github.com/google/go-github/v32/github"
+)
+
+const labelColor = "ededed"
+
+// PullRequestFile describes a changed file in the PR title and body templates.
+type PullRequestFile struct {
+	Path      string
+	Action    config.FileAction
+	Additions int
+	Deletions int
+}
+
+// pullRequestContent renders the title and body templates of the PR. Both are markdown and not HTML, so
+// they are rendered without escaping.
+func pullRequestContent(opts *config.Config, intent *config.RepositoryUpdate) (string, string, error) {
+	titleTemplate := opts.PullRequest.Title
+	if titleTemplate == "" {
+		titleTemplate = config.PullRequestTitle
+	}
+	bodyTemplate := opts.PullRequest.Body
+	if bodyTemplate == "" {
+		bodyTemplate = config.PullRequestBody
+	}
+
+	templateVars := config.TemplateVars{}
+	for k, v := range intent.TemplateVars {
+		templateVars[k] = v
+	}
+	templateVars["Files"] = pullRequestFiles(intent)
+
+	title, err := executeTextTemplate("pull-request-title", titleTemplate, templateVars)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := executeTextTemplate("pull-request-body", bodyTemplate, templateVars)
+	if err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSpace(title.String()), strings.TrimSpace(body.String()), nil
+}
+
+func executeTextTemplate(name string, text string, templateVars config.TemplateVars) (*bytes.Buffer, error) {
+	t, err := template.New(name).
+		Delims("$((", "))").
+		Funcs(sprig.TxtFuncMap()).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template %v: %w", name, err)
+	}
+
+	bytesCache := new(bytes.Buffer)
+	err = t.Execute(bytesCache, templateVars)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute template %v: %w", name, err)
+	}
+
+	return bytesCache, nil
+}
+
+func pullRequestFiles(intent *config.RepositoryUpdate) []*PullRequestFile {
+	files := []*PullRequestFile{}
+	for _, file := range intent.Files {
+		remote := []byte{}
+		if file.RepositoryUpdateOptions.RemoteFileContent != nil {
+			remote = *file.RepositoryUpdateOptions.RemoteFileContent
+		}
+		additions, deletions := diffStat(file.RepositoryUpdateOptions.Path, remote, *file.RepositoryUpdateOptions.FileContent)
+		files = append(files, &PullRequestFile{
+			Path:      file.RepositoryUpdateOptions.Path,
+			Action:    file.RepositoryUpdateOptions.Action,
+			Additions: additions,
+			Deletions: deletions,
+		})
+	}
+	return files
+}
+
+// diffStat counts the added and deleted lines of a file.
+func diffStat(filePath string, remote, local []byte) (int, int) {
+	diff, err := UnifiedDiff(filePath, remote, local)
+	if err != nil {
+		return 0, 0
+	}
+	additions, deletions := 0, 0
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			additions++
+		case strings.HasPrefix(line, "-"):
+			deletions++
+		}
+	}
+	return additions, deletions
+}
+
+// applyPullRequestMetadata adds the labels, assignees, reviewers and the milestone to the PR.
+// Labels which don't exist in the repository are created.
+func applyPullRequestMetadata(opts *config.Config, intent *config.RepositoryUpdate, number int) error {
+	owner := intent.RepositoryOptions.Owner
+	repo := intent.RepositoryOptions.Repo
+	prOpts := opts.PullRequest
+
+	if len(prOpts.Labels) > 0 {
+		for _, label := range prOpts.Labels {
+			err := ensureLabel(opts, owner, repo, label)
+			if err != nil {
+				return err
+			}
+		}
+		_, _, err := opts.GithubClient.Issues.AddLabelsToIssue(opts.Context, owner, repo, number, prOpts.Labels)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(prOpts.Assignees) > 0 {
+		_, _, err := opts.GithubClient.Issues.AddAssignees(opts.Context, owner, repo, number, prOpts.Assignees)
+		if err != nil {
+			return err
+		}
+	}
+
+	if prOpts.Milestone > 0 {
+		_, _, err := opts.GithubClient.Issues.Edit(opts.Context, owner, repo, number, &github.IssueRequest{
+			Milestone: &prOpts.Milestone,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(prOpts.Reviewers) > 0 || len(prOpts.TeamReviewers) > 0 {
+		_, _, err := opts.GithubClient.PullRequests.RequestReviewers(opts.Context, owner, repo, number, github.ReviewersRequest{
+			Reviewers:     prOpts.Reviewers,
+			TeamReviewers: prOpts.TeamReviewers,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ensureLabel(opts *config.Config, owner, repo, name string) error {
+	_, resp, err := opts.GithubClient.Issues.GetLabel(opts.Context, owner, repo, name)
+	if err == nil {
+		return nil
+	}
+	if resp == nil || resp.StatusCode != 404 {
+		return err
+	}
+
+	_, _, err = opts.GithubClient.Issues.CreateLabel(opts.Context, owner, repo, &github.Label{
+		Name:  &name,
+		Color: github.String(labelColor),
+	})
+	return err
+}
+
+// setDraft converts the PR to a draft or marks it as ready for review. The REST API can't change the
+// draft state of an existing PR, so the GraphQL API is used.
+func setDraft(opts *config.Config, pr *github.PullRequest, draft bool) error {
+	if pr.GetDraft() == draft {
+		return nil
+	}
+
+	mutation := "markPullRequestReadyForReview"
+	if draft {
+		mutation = "convertPullRequestToDraft"
+	}
+	query := map[string]interface{}{
+		"query":     fmt.Sprintf("mutation($id: ID!) { %s(input: {pullRequestId: $id}) { clientMutationId } }", mutation),
+		"variables": map[string]interface{}{"id": pr.GetNodeID()},
+	}
+
+	req, err := opts.GithubClient.NewRequest("POST", graphQLURL(opts.GithubClient), query)
+	if err != nil {
+		return err
+	}
+	result := struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	_, err = opts.GithubClient.Do(opts.Context, req, &result)
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("could not change the draft state of PR #%v: %v", pr.GetNumber(), result.Errors[0].Message)
+	}
+
+	return nil
+}
+
+// graphQLURL returns the GraphQL endpoint of the API. It's "/api/graphql" on GitHub Enterprise Server
+// and "/graphql" next to the REST API on github.com.
+func graphQLURL(client *github.Client) string {
+	u := *client.BaseURL
+	if strings.HasSuffix(u.Path, "/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+		return u.String()
+	}
+	u.Path += "graphql"
+	return u.String()
+}
@@ -0,0 +1,101 @@
+package helper
+
+import (
+	"ghconfig/internal/common"
+	"ghconfig/internal/config"
+	"path"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+const stateFileHeader = "# Managed by ghconfig. The last applied templates are the common ancestor of the three-way merge.\n"
+
+// GetState reads the state file of the base branch. A missing state file results in an empty state.
+func GetState(opts *config.Config, repoOpts *config.RepositoryUpdateOptions) (*config.State, error) {
+	state := &config.State{Files: map[string]string{}}
+
+	remoteFile, resp, err := GetRemoteFile(opts, repoOpts, config.StateFilePath)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(remoteFile.Data, state)
+	if err != nil {
+		return nil, err
+	}
+	if state.Files == nil {
+		state.Files = map[string]string{}
+	}
+	state.Remote = remoteFile
+	return state, nil
+}
+
+// StateFile returns the update of the state file with the templates of all files.
+func StateFile(state *config.State, files []*config.RepositoryFileUpdate) (*config.RepositoryFileUpdate, error) {
+	next := &config.State{Files: map[string]string{}}
+	for filePath, template := range state.Files {
+		next.Files[filePath] = template
+	}
+	for _, file := range files {
+		if file.RepositoryUpdateOptions.Template != nil {
+			next.Files[file.RepositoryUpdateOptions.Path] = string(file.RepositoryUpdateOptions.Template)
+		}
+	}
+
+	data, err := yaml.Marshal(next)
+	if err != nil {
+		return nil, err
+	}
+	content := append([]byte(stateFileHeader), data...)
+
+	file := &config.RepositoryFileUpdate{}
+	file.RepositoryUpdateOptions = &config.RepositoryFileUpdateOptions{}
+	file.RepositoryUpdateOptions.Filename = path.Base(config.StateFilePath)
+	file.RepositoryUpdateOptions.DisplayName = file.RepositoryUpdateOptions.Filename
+	file.RepositoryUpdateOptions.Path = config.StateFilePath
+	file.RepositoryUpdateOptions.FileContent = &content
+	file.RepositoryUpdateOptions.Action = config.FileCreated
+	if state.Remote != nil {
+		file.RepositoryUpdateOptions.Action = config.FileMerged
+		file.RepositoryUpdateOptions.RemoteFileContent = &state.Remote.Data
+		file.RepositoryUpdateOptions.SHA = state.Remote.Content.GetSHA()
+	}
+	return file, nil
+}
+
+// ThreeWayMerge merges the remote file and the local template with the last applied template base as
+// common ancestor and decodes the merged file into result. remote and local must have the type of result.
+// It returns the paths which were changed differently in the template and the remote file.
+func ThreeWayMerge(base []byte, remote, local, result interface{}) ([]string, error) {
+	baseValue := reflect.New(reflect.TypeOf(result).Elem()).Interface()
+	err := yaml.Unmarshal(base, baseValue)
+	if err != nil {
+		return nil, err
+	}
+
+	// all trees are created from the same type so that equal values have the same notation
+	baseTree, err := common.ToTree(baseValue)
+	if err != nil {
+		return nil, err
+	}
+	remoteTree, err := common.ToTree(remote)
+	if err != nil {
+		return nil, err
+	}
+	localTree, err := common.ToTree(local)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, conflicts := common.ThreeWayMerge(baseTree, remoteTree, localTree)
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	return conflicts, yaml.Unmarshal(data, result)
+}
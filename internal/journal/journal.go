@@ -0,0 +1,113 @@
+package journal
+
+import (
+	"encoding/json"
+	"ghconfig/internal/config"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type (
+	// Journal records the outcome of every repository so that an interrupted run can be resumed.
+	// It's saved after every record.
+	Journal struct {
+		mu           sync.Mutex
+		filename     string
+		Repositories map[string]*Entry `json:"repositories"`
+	}
+
+	Entry struct {
+		Status config.RepositoryStatus `json:"status"`
+		// Revision identifies the templates which were applied
+		Revision       string    `json:"revision"`
+		Branch         string    `json:"branch,omitempty"`
+		CommitSHA      string    `json:"commit_sha,omitempty"`
+		PullRequestURL string    `json:"pull_request_url,omitempty"`
+		Errors         []string  `json:"errors,omitempty"`
+		UpdatedAt      time.Time `json:"updated_at"`
+	}
+)
+
+// Open reads the journal. A missing file results in an empty journal.
+func Open(filename string) (*Journal, error) {
+	j := &Journal{
+		filename:     filename,
+		Repositories: map[string]*Entry{},
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return j, nil
+		}
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, j)
+	if err != nil {
+		return nil, err
+	}
+	if j.Repositories == nil {
+		j.Repositories = map[string]*Entry{}
+	}
+
+	return j, nil
+}
+
+// Get returns the entry of the repository or nil.
+func (j *Journal) Get(repository string) *Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.Repositories[repository]
+}
+
+// Completed reports whether the repository was updated or found up to date with the same revision.
+func (j *Journal) Completed(repository, revision string) bool {
+	entry := j.Get(repository)
+	if entry == nil || entry.Revision != revision {
+		return false
+	}
+	return entry.Status == config.StatusUpdated || entry.Status == config.StatusUnchanged
+}
+
+// Record stores the entry of the repository and saves the journal.
+func (j *Journal) Record(repository string, entry *Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if entry.UpdatedAt.IsZero() {
+		entry.UpdatedAt = time.Now().UTC()
+	}
+	j.Repositories[repository] = entry
+
+	return j.save()
+}
+
+// save writes the journal atomically so that a crash never leaves a corrupted file.
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(j.filename), filepath.Base(j.filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), j.filename)
+}
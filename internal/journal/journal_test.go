@@ -0,0 +1,59 @@
+package journal
+
+import (
+	"ghconfig/internal/config"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "ghconfig-journal.json")
+
+	j, err := Open(filename)
+	assert.Nil(t, err)
+	assert.Nil(t, j.Get("o/r"))
+
+	assert.Nil(t, j.Record("o/r", &Entry{Status: config.StatusUpdated, Revision: "a", Branch: "ghconfig/workflows/1", PullRequestURL: "https://github.com/o/r/pull/1"}))
+	assert.Nil(t, j.Record("o/failed", &Entry{Status: config.StatusFailed, Revision: "a", Errors: []string{"boom"}}))
+	assert.Nil(t, j.Record("o/unchanged", &Entry{Status: config.StatusUnchanged, Revision: "a"}))
+
+	j, err = Open(filename)
+	assert.Nil(t, err)
+	assert.Len(t, j.Repositories, 3)
+	assert.Equal(t, "https://github.com/o/r/pull/1", j.Get("o/r").PullRequestURL)
+	assert.False(t, j.Get("o/r").UpdatedAt.IsZero())
+
+	type completedTestCase struct {
+		Description string
+		Repository  string
+		Revision    string
+		Completed   bool
+	}
+
+	testcases := []completedTestCase{
+		{Description: "Updated", Repository: "o/r", Revision: "a", Completed: true},
+		{Description: "Unchanged", Repository: "o/unchanged", Revision: "a", Completed: true},
+		{Description: "Other revision", Repository: "o/r", Revision: "b", Completed: false},
+		{Description: "Failed", Repository: "o/failed", Revision: "a", Completed: false},
+		{Description: "Unknown", Repository: "o/unknown", Revision: "a", Completed: false},
+	}
+
+	for _, testcase := range testcases {
+		assert.Equal(t, testcase.Completed, j.Completed(testcase.Repository, testcase.Revision), testcase.Description)
+	}
+
+	// no temporary files are left
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+}
@@ -0,0 +1,233 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+const (
+	defaultMaxRetries = 5
+	// GitHub recommends to wait at least one minute after a secondary rate limit without Retry-After
+	secondaryLimitBackoff = time.Minute
+	initialBackoff        = time.Second
+	maxBackoff            = 5 * time.Minute
+)
+
+// Transport is a http.RoundTripper which respects the rate limits of the GitHub API.
+// It waits for the reset of the primary rate limit, backs off and retries on secondary rate limits
+// (403/429) and server errors (5xx) of idempotent requests and counts the requests of an optional budget.
+type Transport struct {
+	Base http.RoundTripper
+	// MaxRetries is the maximum of retries per request
+	MaxRetries int
+	// MaxRequests is the request budget. It's checked with Exhausted before a repository is started so that
+	// repositories in progress are completed and no half-updated branches are left behind. Zero means unlimited.
+	MaxRequests int64
+
+	// sleep can be replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu        sync.Mutex
+	requests  int64
+	remaining int
+	limit     int
+	reset     time.Time
+}
+
+// NewTransport wraps base. A nil base uses http.DefaultTransport.
+func NewTransport(base http.RoundTripper, maxRequests int64) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		Base:        base,
+		MaxRetries:  defaultMaxRetries,
+		MaxRequests: maxRequests,
+		sleep:       sleep,
+		remaining:   -1,
+	}
+}
+
+// Remaining returns the remaining requests and the limit of the primary rate limit.
+// ok is false until the first response was received.
+func (t *Transport) Remaining() (remaining int, limit int, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.remaining, t.limit, t.remaining >= 0
+}
+
+// Requests returns the number of requests which were sent.
+func (t *Transport) Requests() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.requests
+}
+
+// Exhausted reports whether the request budget is used up.
+func (t *Transport) Exhausted() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.MaxRequests > 0 && t.requests >= t.MaxRequests
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		if err := t.waitForReset(ctx); err != nil {
+			return nil, err
+		}
+		t.count()
+
+		r := req
+		if attempt > 0 {
+			r = req.Clone(ctx)
+			if req.Body != nil && req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := t.Base.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		t.update(resp.Header)
+
+		wait, retry := t.backoff(req, resp, attempt)
+		if !retry || attempt >= t.MaxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		log.Debugf("retry %v %v in %v (status %d)", req.Method, req.URL.Path, wait, resp.StatusCode)
+
+		if err := t.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *Transport) count() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests++
+}
+
+// waitForReset blocks until the primary rate limit is reset when no requests are left.
+func (t *Transport) waitForReset(ctx context.Context) error {
+	t.mu.Lock()
+	wait := time.Duration(0)
+	if t.remaining == 0 {
+		wait = time.Until(t.reset)
+	}
+	t.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	log.Warnf("rate limit exceeded, waiting %v for reset", wait.Round(time.Second))
+	return t.sleep(ctx, wait)
+}
+
+func (t *Transport) update(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.remaining = remaining
+	if limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		t.limit = limit
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		t.reset = time.Unix(reset, 0)
+	}
+}
+
+// backoff reports whether the response should be retried and how long to wait before.
+func (t *Transport) backoff(req *http.Request, resp *http.Response, attempt int) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+	case resp.StatusCode >= 500:
+		// the request might have been processed e.g a PR was created
+		if !isIdempotent(req.Method) {
+			return 0, false
+		}
+	case resp.StatusCode == http.StatusForbidden && isRateLimited(resp):
+	default:
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Until(time.Unix(reset, 0)), true
+		}
+	}
+
+	if resp.StatusCode < 500 {
+		return secondaryLimitBackoff, true
+	}
+
+	wait := initialBackoff << uint(attempt)
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait, true
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isRateLimited distinguishes a rate limited 403 from missing permissions.
+func isRateLimited(resp *http.Response) bool {
+	if resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return true
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	msg := strings.ToLower(string(body))
+	return strings.Contains(msg, "secondary rate limit") || strings.Contains(msg, "abuse")
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,178 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTransport(maxRequests int64) (*Transport, *[]time.Duration) {
+	waits := []time.Duration{}
+	transport := NewTransport(nil, maxRequests)
+	transport.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return transport, &waits
+}
+
+func TestTransport_Retry(t *testing.T) {
+	type retryTestCase struct {
+		Description string
+		Method      string
+		Responses   []func(w http.ResponseWriter)
+		Status      int
+		Waits       []time.Duration
+	}
+
+	rateLimited := func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusForbidden)
+	}
+	secondaryLimit := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit."}`)
+	}
+	forbidden := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "Resource not accessible by integration"}`)
+	}
+	serverError := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	tooManyRequests := func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+	ok := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	testcases := []retryTestCase{
+		{
+			Description: "Retry-After header",
+			Method:      http.MethodPost,
+			Responses:   []func(w http.ResponseWriter){rateLimited, ok},
+			Status:      http.StatusOK,
+			Waits:       []time.Duration{3 * time.Second},
+		},
+		{
+			Description: "Secondary rate limit without Retry-After",
+			Method:      http.MethodPost,
+			Responses:   []func(w http.ResponseWriter){secondaryLimit, tooManyRequests, ok},
+			Status:      http.StatusOK,
+			Waits:       []time.Duration{time.Minute, time.Second},
+		},
+		{
+			Description: "Exponential backoff on server errors",
+			Method:      http.MethodPut,
+			Responses:   []func(w http.ResponseWriter){serverError, serverError, ok},
+			Status:      http.StatusOK,
+			Waits:       []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			Description: "Server errors of non-idempotent requests are not retried",
+			Method:      http.MethodPost,
+			Responses:   []func(w http.ResponseWriter){serverError},
+			Status:      http.StatusBadGateway,
+			Waits:       []time.Duration{},
+		},
+		{
+			Description: "Missing permissions are not retried",
+			Method:      http.MethodPost,
+			Responses:   []func(w http.ResponseWriter){forbidden},
+			Status:      http.StatusForbidden,
+			Waits:       []time.Duration{},
+		},
+	}
+
+	for _, testcase := range testcases {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(t, "payload", string(body), testcase.Description)
+			testcase.Responses[calls](w)
+			calls++
+		}))
+
+		transport, waits := newTestTransport(0)
+		client := &http.Client{Transport: transport}
+
+		req, err := http.NewRequest(testcase.Method, server.URL, strings.NewReader("payload"))
+		assert.Nil(t, err, testcase.Description)
+		resp, err := client.Do(req)
+		assert.Nil(t, err, testcase.Description)
+		assert.Equal(t, testcase.Status, resp.StatusCode, testcase.Description)
+		assert.Equal(t, testcase.Waits, *waits, testcase.Description)
+		assert.Equal(t, len(testcase.Responses), calls, testcase.Description)
+
+		if testcase.Status == http.StatusForbidden {
+			// the body is still readable after the rate limit check
+			body, _ := ioutil.ReadAll(resp.Body)
+			assert.Contains(t, string(body), "not accessible", testcase.Description)
+		}
+		resp.Body.Close()
+		server.Close()
+	}
+}
+
+func TestTransport_RateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	remaining := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(remaining))
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset.Unix()))
+		remaining--
+	}))
+	defer server.Close()
+
+	transport, waits := newTestTransport(0)
+	client := &http.Client{Transport: transport}
+
+	_, _, ok := transport.Remaining()
+	assert.False(t, ok)
+
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	left, limit, ok := transport.Remaining()
+	assert.True(t, ok)
+	assert.Equal(t, 1, left)
+	assert.Equal(t, 5000, limit)
+
+	_, err = client.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Len(t, *waits, 0)
+
+	// no requests left, wait for the reset
+	_, err = client.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Len(t, *waits, 1)
+	assert.InDelta(t, time.Hour.Seconds(), (*waits)[0].Seconds(), 5)
+}
+
+func TestTransport_MaxRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	transport, _ := newTestTransport(2)
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(server.URL)
+		assert.Nil(t, err)
+	}
+	assert.True(t, transport.Exhausted())
+
+	// the budget is checked per repository, requests of a repository in progress are still sent
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), transport.Requests())
+}
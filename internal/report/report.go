@@ -0,0 +1,244 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"ghconfig/internal/config"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatJUnit    = "junit"
+	FormatMarkdown = "markdown"
+)
+
+// Formats are all supported report formats.
+var Formats = []string{FormatJSON, FormatJUnit, FormatMarkdown}
+
+type (
+	// Report is the outcome of a run for every selected repository.
+	Report struct {
+		Repositories []*Repository `json:"repositories"`
+	}
+
+	Repository struct {
+		Name           string                  `json:"name"`
+		Status         config.RepositoryStatus `json:"status"`
+		Files          []*File                 `json:"files,omitempty"`
+		Errors         []string                `json:"errors,omitempty"`
+		PullRequestURL string                  `json:"pull_request_url,omitempty"`
+		CommitSHA      string                  `json:"commit_sha,omitempty"`
+		CommitURL      string                  `json:"commit_url,omitempty"`
+	}
+
+	File struct {
+		Path   string            `json:"path"`
+		Action config.FileAction `json:"action"`
+	}
+
+	junitTestSuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Name     string           `xml:"name,attr"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Skipped  int              `xml:"skipped,attr"`
+		Suites   []junitTestSuite `xml:"testsuite"`
+	}
+
+	junitTestSuite struct {
+		Name      string          `xml:"name,attr"`
+		Tests     int             `xml:"tests,attr"`
+		Failures  int             `xml:"failures,attr"`
+		Skipped   int             `xml:"skipped,attr"`
+		TestCases []junitTestCase `xml:"testcase"`
+	}
+
+	junitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		ClassName string        `xml:"classname,attr"`
+		Failure   *junitMessage `xml:"failure,omitempty"`
+		Skipped   *junitMessage `xml:"skipped,omitempty"`
+		SystemOut string        `xml:"system-out,omitempty"`
+	}
+
+	junitMessage struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// New creates the report of all repository updates.
+func New(updates []*config.RepositoryUpdate) *Report {
+	report := &Report{Repositories: []*Repository{}}
+
+	for _, update := range updates {
+		repository := &Repository{
+			Name:           update.Repository.GetFullName(),
+			Status:         update.Status,
+			Errors:         update.Errors,
+			PullRequestURL: update.PullRequestURL,
+			CommitSHA:      update.CommitSHA,
+		}
+		if update.CommitSHA != "" && update.Repository.GetHTMLURL() != "" {
+			repository.CommitURL = update.Repository.GetHTMLURL() + "/commit/" + update.CommitSHA
+		}
+		if update.Status == config.StatusUpdated {
+			for _, file := range update.Files {
+				repository.Files = append(repository.Files, &File{
+					Path:   file.RepositoryUpdateOptions.Path,
+					Action: file.RepositoryUpdateOptions.Action,
+				})
+			}
+		}
+		report.Repositories = append(report.Repositories, repository)
+	}
+
+	return report
+}
+
+// Count returns the number of repositories with the status.
+func (r *Report) Count(status config.RepositoryStatus) int {
+	count := 0
+	for _, repository := range r.Repositories {
+		if repository.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// WriteFile writes the report in the format to the file.
+func WriteFile(filename, format string, report *Report) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	err = Write(file, format, report)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Write encodes the report in the format. An empty format defaults to JSON.
+func Write(w io.Writer, format string, report *Report) error {
+	switch format {
+	case FormatJSON, "":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FormatJUnit:
+		return writeJUnit(w, report)
+	case FormatMarkdown:
+		return writeMarkdown(w, report)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// writeJUnit maps every repository to a test case so that CI systems can alert on failures.
+func writeJUnit(w io.Writer, report *Report) error {
+	suite := junitTestSuite{
+		Name:     "ghconfig",
+		Tests:    len(report.Repositories),
+		Failures: report.Count(config.StatusFailed),
+		Skipped:  report.Count(config.StatusSkipped) + report.Count(config.StatusNotStarted),
+	}
+
+	for _, repository := range report.Repositories {
+		testCase := junitTestCase{
+			Name:      repository.Name,
+			ClassName: "ghconfig",
+		}
+		message := strings.Join(repository.Errors, "\n")
+		switch repository.Status {
+		case config.StatusFailed:
+			testCase.Failure = &junitMessage{Message: string(repository.Status), Text: message}
+		case config.StatusSkipped:
+			testCase.Skipped = &junitMessage{Message: message}
+		case config.StatusNotStarted:
+			testCase.Skipped = &junitMessage{Message: string(repository.Status)}
+		default:
+			out := []string{string(repository.Status)}
+			for _, file := range repository.Files {
+				out = append(out, fmt.Sprintf("%v: %v", file.Path, file.Action))
+			}
+			if repository.PullRequestURL != "" {
+				out = append(out, repository.PullRequestURL)
+			}
+			testCase.SystemOut = strings.Join(out, "\n")
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	suites := junitTestSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(suites)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func writeMarkdown(w io.Writer, report *Report) error {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "# ghconfig report\n\n")
+	fmt.Fprintf(b, "%d updated, %d unchanged, %d skipped, %d failed",
+		report.Count(config.StatusUpdated),
+		report.Count(config.StatusUnchanged),
+		report.Count(config.StatusSkipped),
+		report.Count(config.StatusFailed),
+	)
+	if notStarted := report.Count(config.StatusNotStarted); notStarted > 0 {
+		fmt.Fprintf(b, ", %d not started", notStarted)
+	}
+	fmt.Fprintf(b, "\n\n")
+	fmt.Fprintf(b, "| Repository | Status | Files | Link | Errors |\n")
+	fmt.Fprintf(b, "| --- | --- | --- | --- | --- |\n")
+
+	for _, repository := range report.Repositories {
+		files := []string{}
+		for _, file := range repository.Files {
+			files = append(files, fmt.Sprintf("`%v` (%v)", file.Path, file.Action))
+		}
+		link := repository.PullRequestURL
+		if link == "" {
+			link = repository.CommitURL
+		}
+		fmt.Fprintf(b, "| %v | %v | %v | %v | %v |\n",
+			repository.Name,
+			repository.Status,
+			strings.Join(files, "<br>"),
+			link,
+			escapeMarkdown(strings.Join(repository.Errors, "<br>")),
+		)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
@@ -0,0 +1,103 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"ghconfig/internal/config"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestReport() *Report {
+	return New([]*config.RepositoryUpdate{
+		{
+			Repository:     &github.Repository{FullName: github.String("o/a"), HTMLURL: github.String("https://github.com/o/a")},
+			Status:         config.StatusUpdated,
+			PullRequestURL: "https://github.com/o/a/pull/1",
+			CommitSHA:      "abc",
+			Files: []*config.RepositoryFileUpdate{
+				{RepositoryUpdateOptions: &config.RepositoryFileUpdateOptions{Path: ".github/workflows/ci.yaml", Action: config.FileMerged}},
+				{RepositoryUpdateOptions: &config.RepositoryFileUpdateOptions{Path: ".github/dependabot.yml", Action: config.FileCreated}},
+			},
+		},
+		{
+			Repository: &github.Repository{FullName: github.String("o/b")},
+			Status:     config.StatusUnchanged,
+		},
+		{
+			Repository: &github.Repository{FullName: github.String("o/c")},
+			Status:     config.StatusFailed,
+			Errors:     []string{"could not create PR | 422"},
+		},
+		{
+			Repository: &github.Repository{FullName: github.String("o/d")},
+			Status:     config.StatusSkipped,
+			Errors:     []string{"ci.yaml: jobs: invalid"},
+		},
+	})
+}
+
+func TestReport_New(t *testing.T) {
+	report := newTestReport()
+
+	assert.Len(t, report.Repositories, 4)
+	assert.Equal(t, "https://github.com/o/a/commit/abc", report.Repositories[0].CommitURL)
+	assert.Equal(t, []*File{
+		{Path: ".github/workflows/ci.yaml", Action: config.FileMerged},
+		{Path: ".github/dependabot.yml", Action: config.FileCreated},
+	}, report.Repositories[0].Files)
+	assert.Equal(t, 1, report.Count(config.StatusFailed))
+}
+
+func TestReport_Write(t *testing.T) {
+	type formatTestCase struct {
+		Description string
+		Format      string
+		Contains    []string
+	}
+
+	testcases := []formatTestCase{
+		{
+			Description: "JSON",
+			Format:      FormatJSON,
+			Contains:    []string{`"status": "failed"`, `"action": "merged"`, `"pull_request_url": "https://github.com/o/a/pull/1"`},
+		},
+		{
+			Description: "JUnit",
+			Format:      FormatJUnit,
+			Contains: []string{
+				`<testsuites name="ghconfig" tests="4" failures="1" skipped="1">`,
+				`<failure message="failed">could not create PR | 422</failure>`,
+				`<skipped message="ci.yaml: jobs: invalid"></skipped>`,
+			},
+		},
+		{
+			Description: "Markdown",
+			Format:      FormatMarkdown,
+			Contains: []string{
+				"1 updated, 1 unchanged, 1 skipped, 1 failed",
+				"| o/a | updated | `.github/workflows/ci.yaml` (merged)<br>`.github/dependabot.yml` (created) | https://github.com/o/a/pull/1 |  |",
+				"| o/c | failed |  |  | could not create PR \\| 422 |",
+			},
+		},
+	}
+
+	for _, testcase := range testcases {
+		buf := &bytes.Buffer{}
+		err := Write(buf, testcase.Format, newTestReport())
+		assert.Nil(t, err, testcase.Description)
+		for _, s := range testcase.Contains {
+			assert.Contains(t, buf.String(), s, testcase.Description)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	err := Write(buf, FormatJSON, newTestReport())
+	assert.Nil(t, err)
+	assert.True(t, json.Valid(buf.Bytes()))
+
+	err = Write(buf, "yaml", newTestReport())
+	assert.NotNil(t, err)
+}
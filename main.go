@@ -2,9 +2,20 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"ghconfig/cmd"
+	"ghconfig/internal/auth"
 	"ghconfig/internal/config"
+	"ghconfig/internal/helper"
+	"ghconfig/internal/ratelimit"
+	"ghconfig/internal/report"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"os/signal"
+	"path"
+	"syscall"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/cli"
@@ -14,6 +25,8 @@ import (
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
+const requestTimeout = time.Minute
+
 var (
 	appDesc = `ghconfig is a CLI library to manage (.github) repository configurations as a fleet.
 
@@ -23,12 +36,39 @@ var (
 	app             = kingpin.New("ghconfig", appDesc)
 	dryRun          = app.Flag("dry-run", "Runs the command without side-effects.").Bool()
 	baseBranch      = app.Flag("base-branch", "The base branch.").Default("master").Short('b').String()
-	githubToken     = app.Flag("github-token", "Your personal github access token.").OverrideDefaultFromEnvar("GITHUB_TOKEN").Short('t').Required().String()
+	githubToken     = app.Flag("github-token", "Your personal github access token.").OverrideDefaultFromEnvar("GITHUB_TOKEN").Short('t').String()
 	createPR        = app.Flag("create-pr", "Create a new branch and PR for all changes.").Default("true").Short('p').Bool()
 	repositoryQuery = app.Flag("query", "Search query (e.g org:ORGNAME, repo:owner/name)").Short('f').String()
 	commitMessage   = app.Flag("commit-msg", "Git commit message.").Short('m').String()
+	preserveFormat  = app.Flag("preserve-format", "Preserve comments, key order and formatting of remote files.").Default("true").Bool()
+	selectAll       = app.Flag("all", "Select all repositories without a prompt.").Bool()
+	includePatterns = app.Flag("include", "Select repositories whose full name matches the glob or /regex/ without a prompt. Can be repeated.").Strings()
+	excludePatterns = app.Flag("exclude", "Skip repositories whose full name matches the glob or /regex/. Can be repeated.").Strings()
+	reposFile       = app.Flag("repos-file", "Select the repositories listed in the file (one full name per line) without a prompt.").ExistingFile()
+	apiURL          = app.Flag("api-url", "The API URL of your GitHub Enterprise Server (e.g https://github.example.com/api/v3/).").OverrideDefaultFromEnvar("GITHUB_API_URL").String()
+	uploadURL       = app.Flag("upload-url", "The upload URL of your GitHub Enterprise Server. Derived from --api-url by default.").String()
+	appID           = app.Flag("app-id", "Authenticate as GitHub App with the given ID instead of a personal access token.").OverrideDefaultFromEnvar("GITHUB_APP_ID").Int64()
+	appPrivateKey   = app.Flag("app-private-key", "The private key file (PEM) of the GitHub App.").OverrideDefaultFromEnvar("GITHUB_APP_PRIVATE_KEY").ExistingFile()
+	installationID  = app.Flag("installation-id", "The installation ID of the GitHub App.").OverrideDefaultFromEnvar("GITHUB_APP_INSTALLATION_ID").Int64()
+	maxRequests     = app.Flag("max-requests", "Don't start further repositories after the given number of API requests (0 = unlimited).").Default("0").Int64()
+	reportFormat    = app.Flag("report-format", "The format of the report (json, junit, markdown).").Default(report.FormatJSON).Enum(report.Formats...)
+	reportFile      = app.Flag("report-file", "Write a report of every repository outcome to the file.").String()
+	failFast        = app.Flag("fail-fast", "Cancel the run on the first failed repository.").Bool()
+	maxFailures     = app.Flag("max-failures", "Cancel the run after the given number of failed repositories (0 = unlimited).").Default("0").Int()
+	concurrency     = app.Flag("concurrency", "The number of repositories which are processed in parallel.").Default("3").Int()
+	resume          = app.Flag("resume", "Skip repositories which were completed by a previous run with the same templates.").Bool()
+	prTitle         = app.Flag("pr-title", "The Pull-Request title (template).").String()
+	prBodyFile      = app.Flag("pr-body-file", "A file with the Pull-Request description (template).").ExistingFile()
+	prLabels        = app.Flag("pr-label", "Add the label to the Pull-Request. Missing labels are created. Can be repeated.").Strings()
+	prReviewers     = app.Flag("pr-reviewer", "Request a review from the user. Can be repeated.").Strings()
+	prTeamReviewers = app.Flag("pr-team-reviewer", "Request a review from the team (slug). Can be repeated.").Strings()
+	prAssignees     = app.Flag("pr-assignee", "Assign the user to the Pull-Request. Can be repeated.").Strings()
+	prMilestone     = app.Flag("pr-milestone", "The number of the milestone of the Pull-Request.").Int()
+	readyForReview  = app.Flag("ready-for-review", "Open the Pull-Request as ready for review instead of a draft.").Bool()
+	threeWayMerge   = app.Flag("three-way-merge", "Merge with the last applied templates as common ancestor. The templates are recorded in "+config.StateFilePath+".").Bool()
 	syncCommand     = app.Command("sync", "Synchronize all configuration files.")
 	patchCommand    = app.Command("patch", "Apply all JSON patches on existing workflows.")
+	diffCommand     = app.Command("diff", "Show a diff of all configuration files without applying them. Exits with 1 when changes are pending and 2 on errors.")
 )
 
 func main() {
@@ -41,21 +81,39 @@ func main() {
 	log.SetHandler(cli.Default)
 	log.SetLevel(log.InfoLevel)
 
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: *githubToken},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+	pDir, err := os.Getwd()
+	if err != nil {
+		log.WithError(err).Fatalf("could not get wd")
+	}
 
-	sid, err := shortid.New(1, shortid.DefaultABC, 2342)
+	manifest, err := helper.LoadManifest(pDir)
 	if err != nil {
-		log.WithError(err).Fatalf("could not create id generator")
+		log.WithError(err).Fatalf("could not load manifest")
+	}
+	if manifest != nil {
+		if *apiURL == "" {
+			*apiURL = manifest.Github.APIURL
+		}
+		if *uploadURL == "" {
+			*uploadURL = manifest.Github.UploadURL
+		}
 	}
 
-	pDir, err := os.Getwd()
+	ctx := cancelOnSignal(context.Background())
+	appInstallation := *appID != 0
+	client, rateLimit, err := newGithubClient(ctx, appInstallation)
 	if err != nil {
-		log.WithError(err).Fatalf("could not get wd")
+		log.WithError(err).Fatalf("could not create github client")
+	}
+
+	pullRequest, err := pullRequestOptions()
+	if err != nil {
+		log.WithError(err).Fatalf("could not read pull-request options")
+	}
+
+	sid, err := shortid.New(1, shortid.DefaultABC, 2342)
+	if err != nil {
+		log.WithError(err).Fatalf("could not create id generator")
 	}
 
 	switch kingpin.MustParse(app.Parse(os.Args[1:])) {
@@ -70,6 +128,22 @@ func main() {
 			RepositoryQuery: *repositoryQuery,
 			CommitMessage:   *commitMessage,
 			RootDir:         pDir,
+			PreserveFormat:  *preserveFormat,
+			SelectAll:       *selectAll,
+			IncludePatterns: *includePatterns,
+			ExcludePatterns: *excludePatterns,
+			ReposFile:       *reposFile,
+			AppInstallation: appInstallation,
+			RateLimit:       rateLimit,
+			Concurrency:     *concurrency,
+			ReportFormat:    *reportFormat,
+			ReportFile:      *reportFile,
+			FailFast:        *failFast,
+			MaxFailures:     *maxFailures,
+			JournalFile:     path.Join(pDir, config.JournalFileName),
+			Resume:          *resume,
+			PullRequest:     pullRequest,
+			ThreeWayMerge:   *threeWayMerge,
 		}
 		if err := cmd.NewSyncCmd(cfg); err != nil {
 			log.WithError(err).Fatalf("sync command error")
@@ -86,10 +160,127 @@ func main() {
 			RootDir:         pDir,
 			CommitMessage:   *commitMessage,
 			PatchOnly:       true,
+			PreserveFormat:  *preserveFormat,
+			SelectAll:       *selectAll,
+			IncludePatterns: *includePatterns,
+			ExcludePatterns: *excludePatterns,
+			ReposFile:       *reposFile,
+			AppInstallation: appInstallation,
+			RateLimit:       rateLimit,
+			Concurrency:     *concurrency,
+			ReportFormat:    *reportFormat,
+			ReportFile:      *reportFile,
+			FailFast:        *failFast,
+			MaxFailures:     *maxFailures,
+			JournalFile:     path.Join(pDir, config.JournalFileName),
+			Resume:          *resume,
+			PullRequest:     pullRequest,
 		}
 		if err := cmd.NewSyncCmd(cfg); err != nil {
 			log.WithError(err).Fatalf("patch command error")
 		}
+	case diffCommand.FullCommand():
+		cfg := &config.Config{
+			GithubClient:    client,
+			Context:         ctx,
+			BaseBranch:      *baseBranch,
+			Sid:             sid,
+			RepositoryQuery: *repositoryQuery,
+			RootDir:         pDir,
+			PreserveFormat:  *preserveFormat,
+			SelectAll:       *selectAll,
+			IncludePatterns: *includePatterns,
+			ExcludePatterns: *excludePatterns,
+			ReposFile:       *reposFile,
+			AppInstallation: appInstallation,
+			RateLimit:       rateLimit,
+			Concurrency:     *concurrency,
+			ThreeWayMerge:   *threeWayMerge,
+		}
+		hasChanges, err := cmd.NewDiffCmd(cfg)
+		if err != nil {
+			// 1 is reserved for pending changes
+			log.WithError(err).Error("diff command error")
+			os.Exit(2)
+		}
+		if hasChanges {
+			os.Exit(1)
+		}
 	}
+}
+
+// pullRequestOptions collects the Pull-Request flags. Empty title and body fall back to the defaults.
+func pullRequestOptions() (config.PullRequestOptions, error) {
+	opts := config.PullRequestOptions{
+		Title:          *prTitle,
+		Labels:         *prLabels,
+		Reviewers:      *prReviewers,
+		TeamReviewers:  *prTeamReviewers,
+		Assignees:      *prAssignees,
+		Milestone:      *prMilestone,
+		ReadyForReview: *readyForReview,
+	}
+	if *prBodyFile != "" {
+		body, err := ioutil.ReadFile(*prBodyFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.Body = string(body)
+	}
+	return opts, nil
+}
+
+// cancelOnSignal returns a context which is cancelled on SIGINT or SIGTERM so that in-flight API calls stop.
+// A second signal exits immediately.
+func cancelOnSignal(parent context.Context) context.Context {
+	ctx, cancel := context.WithCancel(parent)
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		log.Warn("interrupted, waiting for in-flight repositories to stop (press Ctrl-C again to exit)")
+		cancel()
+		<-signals
+		os.Exit(130)
+	}()
+
+	return ctx
+}
+
+// newGithubClient authenticates with the personal access token or as GitHub App installation.
+// All requests are sent through a rate limit aware transport.
+func newGithubClient(ctx context.Context, appInstallation bool) (*github.Client, *ratelimit.Transport, error) {
+	var ts oauth2.TokenSource
+
+	// the timeout applies to every attempt so that waiting for the rate limit isn't interrupted
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.ResponseHeaderTimeout = requestTimeout
+
+	if appInstallation {
+		if *appPrivateKey == "" || *installationID == 0 {
+			return nil, nil, fmt.Errorf("--app-private-key and --installation-id are required with --app-id")
+		}
+		key, err := ioutil.ReadFile(*appPrivateKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read private key: %w", err)
+		}
+		ts, err = auth.NewInstallationTokenSource(ctx, *appID, *installationID, key, *apiURL, base)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if *githubToken == "" {
+			return nil, nil, fmt.Errorf("--github-token or --app-id is required")
+		}
+		ts = oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: *githubToken},
+		)
+	}
+
+	rateLimit := ratelimit.NewTransport(&oauth2.Transport{Source: ts, Base: base}, *maxRequests)
 
+	client, err := helper.NewGithubClient(&http.Client{Transport: rateLimit}, *apiURL, *uploadURL)
+	return client, rateLimit, err
 }